@@ -0,0 +1,243 @@
+package main
+
+// This file contains a compact board representation for the flood fills and
+// pathfinding. Cells are indexed by y*width+x and sets of cells are bitsets, so
+// probing a cell is a single bit test. Moving the snakes is left to Step.
+
+import (
+	"math"
+)
+
+// bitset is a set of cell indexes
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// bitSnake is a snake whose body is the cell indexes of its segments, head first
+type bitSnake struct {
+	body []int
+}
+
+// segment returns back the cell of the i-th segment counting from the head
+func (s *bitSnake) segment(i int) int {
+	return s.body[i]
+}
+
+func (s *bitSnake) tail() int {
+	return s.body[len(s.body)-1]
+}
+
+type bitBoard struct {
+	width  int
+	height int
+	// occupied has every cell with a snake segment on it
+	occupied bitset
+	food     bitset
+	hazards  bitset
+	// damage is the hazard damage dealt for moving onto each cell
+	damage   []int32
+	snakes   []bitSnake
+	rules    rules
+	topology Topology
+}
+
+// newBitBoard builds a bitBoard from the board
+func newBitBoard(board Board) *bitBoard {
+	cells := board.Width * board.Height
+	bb := &bitBoard{
+		width:    board.Width,
+		height:   board.Height,
+		occupied: newBitset(cells),
+		food:     newBitset(cells),
+		hazards:  newBitset(cells),
+		damage:   make([]int32, cells),
+		snakes:   make([]bitSnake, len(board.Snakes)),
+		rules:    board.ruleset.rules(),
+		topology: board.Topology(),
+	}
+	for _, f := range board.Food {
+		if !board.OutOfBounds(f) {
+			bb.food.set(bb.index(f))
+		}
+	}
+	for _, h := range board.Hazards {
+		if !board.OutOfBounds(h) {
+			bb.hazards.set(bb.index(h))
+			bb.damage[bb.index(h)] += board.ruleset.HazardDamagePerTurn()
+		}
+	}
+	for i, snake := range board.Snakes {
+		body := make([]int, len(snake.Body))
+		for j, c := range snake.Body {
+			body[j] = bb.index(c)
+			if !board.OutOfBounds(c) {
+				bb.occupied.set(body[j])
+			}
+		}
+		bb.snakes[i] = bitSnake{body: body}
+	}
+	return bb
+}
+
+func (bb *bitBoard) index(c Coord) int {
+	return c.Y*bb.width + c.X
+}
+
+func (bb *bitBoard) coord(i int) Coord {
+	return Coord{i % bb.width, i / bb.width}
+}
+
+func (bb *bitBoard) outOfBounds(c Coord) bool {
+	return c.X < 0 || c.X >= bb.width || c.Y < 0 || c.Y >= bb.height
+}
+
+// blocked mirrors Board.Occupied: the cell has a snake segment that won't move
+// out of the way next turn, which is anything but a tail
+func (bb *bitBoard) blocked(i int) bool {
+	if !bb.occupied.has(i) {
+		return false
+	}
+	if bb.rules.GrowsEveryTurn {
+		return true
+	}
+	for j := range bb.snakes {
+		s := &bb.snakes[j]
+		if len(s.body) == 0 || s.tail() != i {
+			continue
+		}
+		// a tail moves out of the way unless another segment is stacked on it
+		if len(s.body) < 2 || s.segment(len(s.body)-2) != i {
+			return false
+		}
+	}
+	return true
+}
+
+// openSpace is what a timed flood fill found reachable
+type openSpace struct {
+	// area is the number of cells reachable, not counting the start
+	area int
+	// safe is the number of those cells without hazard
+	safe int
+	// escapeTurns is the turn on which the first cell left by a moving body is
+	// reached, or -1 if every reachable cell was free to begin with
+	escapeTurns int
+}
+
+// never is when a cell that never frees up frees up
+const never = math.MaxInt32
+
+// deadEnd returns back whether a snake of the given length would run out of
+// room in the space: it is too small to hold the snake and no body moves out of
+// the way in time
+func (s openSpace) deadEnd(length int) bool {
+	return s.area < length && s.escapeTurns < 0
+}
+
+// timedFloodFill returns back the cells reachable from the coordinate, not
+// counting the coordinate itself, with bodies moving out of the way: the
+// segment k places from the head of a snake of length n is gone after n-k
+// turns, so its cell is reachable from n-k moves away. Snakes are assumed not to eat, so
+// bodies that grow free up a turn later than expected, unless they grow every
+// turn in which case they never free up.
+//
+// Moving costs health like it does in Step, so cells only reachable through
+// more hazard than the snake's health can take are left out.
+func (bb *bitBoard) timedFloodFill(from Coord, health int32) openSpace {
+	space := openSpace{escapeTurns: -1}
+	if bb.outOfBounds(from) {
+		return space
+	}
+
+	cells := bb.width * bb.height
+	freeAt := bb.freeAt()
+
+	// arrived is the turn each cell was first reached on, plus one so that zero
+	// is unreached, and healthAt the most health the snake can get there with
+	arrived := make([]int, cells)
+	healthAt := make([]int32, cells)
+	start := bb.index(from)
+	arrived[start] = 1
+	healthAt[start] = health
+	frontier := []int{start}
+	for turn := 1; len(frontier) > 0; turn++ {
+		next := []int{}
+		for _, cell := range frontier {
+			c := bb.coord(cell)
+			for _, dir := range directions {
+				n := bb.topology.Move(c, dir)
+				if bb.outOfBounds(n) {
+					continue
+				}
+				// cells that are not free yet stay unreached so a later, longer
+				// path can still reach them
+				i := bb.index(n)
+				if (arrived[i] != 0 && arrived[i] <= turn) || freeAt[i] > turn {
+					continue
+				}
+				h := healthAt[cell] - 1
+				if bb.food.has(i) {
+					h = SnakeMaxHealth
+				} else {
+					h -= bb.damage[i]
+				}
+				if h <= 0 {
+					continue
+				}
+				if arrived[i] != 0 {
+					// reached already this turn along another path
+					if h > healthAt[i] {
+						healthAt[i] = h
+					}
+					continue
+				}
+				if freeAt[i] > 0 && space.escapeTurns < 0 {
+					space.escapeTurns = turn
+				}
+				arrived[i] = turn + 1
+				healthAt[i] = h
+				next = append(next, i)
+			}
+		}
+		frontier = next
+	}
+	for i := range arrived {
+		if arrived[i] != 0 && i != start {
+			space.area++
+			if !bb.hazards.has(i) {
+				space.safe++
+			}
+		}
+	}
+	return space
+}
+
+// freeAt returns back the turn on which each cell is free of bodies, the way
+// timedFloodFill times them
+func (bb *bitBoard) freeAt() []int {
+	cells := bb.width * bb.height
+	freeAt := make([]int, cells)
+	for i := range bb.snakes {
+		s := &bb.snakes[i]
+		// from the tail up so a stacked segment keeps the later turn
+		for j := len(s.body) - 1; j >= 0; j-- {
+			if c := s.segment(j); c >= 0 && c < cells {
+				freeAt[c] = len(s.body) - j
+				if bb.rules.GrowsEveryTurn {
+					freeAt[c] = never
+				}
+			}
+		}
+	}
+	return freeAt
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceOpenSpaces is the flood fill numOpenSpaces used before bitboards, probing
+// Board.Occupied for every cell. It is kept to check and benchmark against.
+func sliceOpenSpaces(head Coord, board Board) int {
+	set := map[Coord]bool{}
+	var recurse func(target Coord)
+	recurse = func(target Coord) {
+		if _, done := set[target]; done || (target != head && (board.OutOfBounds(target) || board.Occupied(target))) {
+			return
+		}
+		set[target] = true
+		for _, dir := range directions {
+			recurse(target.Add(Coord(dir)))
+		}
+	}
+	recurse(head)
+	return len(set) - 1
+}
+
+// randomGame plays random safe moves from a four snake start, returning back
+// every state along the way
+func randomGame(seed int64, turns int) []GameState {
+	rng := rand.New(rand.NewSource(seed))
+	state := newTestState(11, 11, []Coord{{5, 5}, {0, 2}, {8, 10}},
+		newTestSnake("a", 100, Coord{1, 1}, Coord{1, 1}, Coord{1, 1}),
+		newTestSnake("b", 100, Coord{9, 9}, Coord{9, 9}, Coord{9, 9}),
+		newTestSnake("c", 100, Coord{1, 9}, Coord{1, 9}, Coord{1, 9}),
+		newTestSnake("d", 100, Coord{9, 1}, Coord{9, 1}, Coord{9, 1}),
+	)
+	states := []GameState{state}
+	for turn := 0; turn < turns && len(state.Board.Snakes) > 0; turn++ {
+		moves := map[string]BattlesnakeMove{}
+		for _, snake := range state.Board.Snakes {
+			// mostly safe moves, but sometimes anything to exercise eliminations
+			options := candidateMoves(snake, state.Board)
+			if rng.Intn(30) == 0 {
+				options = []BattlesnakeMove{BattlesnakeMove_Up, BattlesnakeMove_Down, BattlesnakeMove_Left, BattlesnakeMove_Right}
+			}
+			moves[snake.ID] = options[rng.Intn(len(options))]
+		}
+		state = Step(state, moves)
+		if rng.Intn(4) == 0 {
+			state.Board.Food = append(state.Board.Food, Coord{rng.Intn(11), rng.Intn(11)})
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func TestBitBoardBlocked(t *testing.T) {
+	for _, state := range randomGame(1, 100) {
+		bb := newBitBoard(state.Board)
+		for _, c := range allCoords(state.Board) {
+			assert.Equal(t, state.Board.Occupied(c), bb.blocked(bb.index(c)), "turn %d %v", state.Turn, c)
+		}
+	}
+}
+
+func TestBitBoardTimedFloodFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   GameState
+		space   openSpace
+		deadEnd bool
+	}{
+		{
+			name: "coiled up",
+			state: newTestState(3, 3, nil,
+				newTestSnake("me", 100, Coord{1, 1}, Coord{0, 1}, Coord{0, 2}, Coord{1, 2}, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}, Coord{1, 0}),
+			),
+			space: openSpace{area: 8, safe: 8, escapeTurns: 1},
+		},
+		{
+			name: "body leaves too late",
+			state: newTestState(3, 3, nil,
+				newTestSnake("me", 100, Coord{0, 0}, Coord{0, 0}, Coord{0, 0}, Coord{0, 0}),
+				newTestSnake("them", 100, Coord{1, 0}, Coord{1, 1}, Coord{1, 2}, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}),
+			),
+			space:   openSpace{area: 2, safe: 2, escapeTurns: -1},
+			deadEnd: true,
+		},
+		{
+			name: "body leaves in time",
+			state: newTestState(3, 3, nil,
+				newTestSnake("me", 100, Coord{0, 0}, Coord{0, 0}, Coord{0, 0}, Coord{0, 0}),
+				newTestSnake("them", 100, Coord{1, 0}, Coord{1, 1}, Coord{1, 2}, Coord{2, 2}),
+			),
+			space: openSpace{area: 8, safe: 8, escapeTurns: 3},
+		},
+		{
+			name: "not enough health to cross hazard",
+			state: withHazards(newTestState(3, 3, nil,
+				newTestSnake("me", 15, Coord{0, 0}),
+			), Coord{1, 0}, Coord{1, 1}, Coord{1, 2}),
+			space: openSpace{area: 2, safe: 2, escapeTurns: -1},
+		},
+		{
+			name: "enough health to cross hazard",
+			state: withHazards(newTestState(3, 3, nil,
+				newTestSnake("me", 40, Coord{0, 0}),
+			), Coord{1, 0}, Coord{1, 1}, Coord{1, 2}),
+			space: openSpace{area: 8, safe: 5, escapeTurns: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space := newBitBoard(tt.state.Board).timedFloodFill(tt.state.You.Head, tt.state.You.Health)
+			assert.Equal(t, tt.space, space)
+			assert.Equal(t, tt.deadEnd, space.deadEnd(len(tt.state.You.Body)))
+		})
+	}
+}
+
+func TestBitBoardTimedFloodFillReachesMore(t *testing.T) {
+	for _, state := range randomGame(4, 60) {
+		bb := newBitBoard(state.Board)
+		for _, snake := range state.Board.Snakes {
+			assert.GreaterOrEqual(t, bb.timedFloodFill(snake.Head, snake.Health).area, sliceOpenSpaces(snake.Head, state.Board))
+		}
+	}
+}
+
+func withHazards(state GameState, hazards ...Coord) GameState {
+	state.Board.Hazards = hazards
+	return state
+}
+
+func allCoords(board Board) []Coord {
+	coords := []Coord{}
+	for x := 0; x < board.Width; x++ {
+		for y := 0; y < board.Height; y++ {
+			coords = append(coords, Coord{x, y})
+		}
+	}
+	return coords
+}
+
+// benchmarkState is midway through a game with three snakes left
+func benchmarkState() GameState {
+	return randomGame(13, 50)[50]
+}
+
+func BenchmarkOccupied(b *testing.B) {
+	state := benchmarkState()
+	coords := allCoords(state.Board)
+
+	b.Run("slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, c := range coords {
+				_ = state.Board.Occupied(c)
+			}
+		}
+	})
+	b.Run("bitboard", func(b *testing.B) {
+		bb := newBitBoard(state.Board)
+		for i := 0; i < b.N; i++ {
+			for _, c := range coords {
+				_ = bb.blocked(bb.index(c))
+			}
+		}
+	})
+}
+
+func BenchmarkOpenSpaces(b *testing.B) {
+	state := benchmarkState()
+	head := state.Board.Snakes[0].Head
+
+	b.Run("slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = sliceOpenSpaces(head, state.Board)
+		}
+	})
+	b.Run("timed", func(b *testing.B) {
+		bb := newBitBoard(state.Board)
+		for i := 0; i < b.N; i++ {
+			_ = bb.timedFloodFill(head, state.Board.Snakes[0].Health)
+		}
+	})
+	b.Run("timed with conversion", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = newBitBoard(state.Board).timedFloodFill(head, state.Board.Snakes[0].Health)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+// This file contains the chokepoint finder. The cells free next turn make up a
+// graph with an edge between neighbouring cells. An articulation point is a cell
+// that splits the part of the graph it is in when it is taken, so a snake moving
+// onto one has to pick a side. They are found in a single depth-first search with
+// Tarjan's algorithm, along with the size of every side of every split.
+//
+// See https://en.wikipedia.org/wiki/Biconnected_component
+
+import (
+	"sort"
+)
+
+// chokepoints are the articulation points of the free cells of a board
+type chokepoints struct {
+	// sides are, for every articulation point, the number of free cells in each
+	// part its part of the graph splits into when it is taken, largest first
+	sides map[Coord][]int
+}
+
+// findChokepoints returns back the chokepoints of the cells that are free next
+// turn, which are the cells without a body except for tails about to move
+func findChokepoints(board Board) chokepoints {
+	result := chokepoints{sides: map[Coord][]int{}}
+	if board.Width*board.Height == 0 {
+		return result
+	}
+	bb := newBitBoard(board)
+	cells := bb.width * bb.height
+	freeAt := bb.freeAt()
+	free := func(i int) bool {
+		return freeAt[i] <= 1
+	}
+
+	// discovered is the order each cell was first visited in, plus one so that
+	// zero is unvisited, and low the earliest discovered cell reachable from its
+	// subtree through a single edge back up
+	discovered := make([]int, cells)
+	low := make([]int, cells)
+	size := make([]int, cells)
+	// separated are the sizes of the subtrees that only join the rest of the
+	// graph through each cell
+	separated := make([][]int, cells)
+	order := 0
+	// visited are the cells of the part being searched
+	visited := []int{}
+
+	var visit func(cell, parent int)
+	visit = func(cell, parent int) {
+		order++
+		visited = append(visited, cell)
+		discovered[cell], low[cell], size[cell] = order, order, 1
+		c := bb.coord(cell)
+		// a board two cells across wraps onto the same neighbour both ways, and
+		// only one of those edges leads back to the parent
+		skippedParent := false
+		for _, dir := range directions {
+			n := bb.topology.Move(c, dir)
+			if bb.outOfBounds(n) {
+				continue
+			}
+			next := bb.index(n)
+			if next == cell || !free(next) {
+				continue
+			}
+			if next == parent && !skippedParent {
+				skippedParent = true
+				continue
+			}
+			if discovered[next] != 0 {
+				if discovered[next] < low[cell] {
+					low[cell] = discovered[next]
+				}
+				continue
+			}
+			visit(next, cell)
+			size[cell] += size[next]
+			if low[next] < low[cell] {
+				low[cell] = low[next]
+			}
+			if low[next] >= discovered[cell] {
+				separated[cell] = append(separated[cell], size[next])
+			}
+		}
+	}
+
+	for root := 0; root < cells; root++ {
+		if !free(root) || discovered[root] != 0 {
+			continue
+		}
+		visited = visited[:0]
+		visit(root, -1)
+		total := size[root]
+		for _, cell := range visited {
+			if len(separated[cell]) == 0 {
+				continue
+			}
+			sides := separated[cell]
+			if cell != root {
+				// the side the search came in from
+				rest := total - 1
+				for _, s := range sides {
+					rest -= s
+				}
+				sides = append(sides, rest)
+			} else if len(sides) < 2 {
+				// a root with a single subtree doesn't split anything
+				continue
+			}
+			sort.Sort(sort.Reverse(sort.IntSlice(sides)))
+			result.sides[bb.coord(cell)] = sides
+		}
+	}
+	return result
+}
+
+// split returns back the sizes of the parts the free cells around the cell split
+// into when it is taken, largest first, or nil if taking it splits nothing
+func (c chokepoints) split(cell Coord) []int {
+	return c.sides[cell]
+}
+
+// committedSpace returns back the share of the free cells around the cell a snake
+// moving onto it can still get to: all of them, unless the cell splits them and
+// the snake has to commit to the largest side
+func (c chokepoints) committedSpace(cell Coord) float64 {
+	sides := c.split(cell)
+	if len(sides) == 0 {
+		return 1
+	}
+	total := 0
+	for _, s := range sides {
+		total += s
+	}
+	return float64(sides[0]+1) / float64(total+1)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gapState is a 5x5 board with a wall up column 2 that leaves a gap at the top,
+// and our snake in the right half
+func gapState() GameState {
+	return newTestState(5, 5, nil,
+		newTestSnake("me", 100, Coord{4, 4}, Coord{4, 3}, Coord{4, 2}),
+		newTestSnake("wall", 100, Coord{2, 3}, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}, Coord{1, 0}),
+	)
+}
+
+func TestFindChokepoints(t *testing.T) {
+	chokes := findChokepoints(gapState().Board)
+	// tails are about to move so they count as free
+	assert.Equal(t, []int{10, 8}, chokes.split(Coord{2, 4}))
+	assert.Nil(t, chokes.split(Coord{0, 0}))
+	// either side of the gap splits off everything beyond it
+	assert.Equal(t, []int{9, 9}, chokes.split(Coord{1, 4}))
+	assert.Equal(t, []int{11, 7}, chokes.split(Coord{3, 4}))
+	assert.InDelta(t, 11.0/19, chokes.committedSpace(Coord{2, 4}), 1e-9)
+	assert.Equal(t, 1.0, chokes.committedSpace(Coord{0, 0}))
+}
+
+func TestFindChokepointsWrapped(t *testing.T) {
+	// on a torus two cells across every cell has two ways round, even with both
+	// neighbours across the edge being the same cell
+	state := newTestState(2, 3, nil, newTestSnake("me", 100, Coord{0, 0}))
+	state.Board.ruleset = Ruleset{Name: rulesetWrapped}
+	chokes := findChokepoints(state.Board)
+	assert.Empty(t, chokes.sides)
+}
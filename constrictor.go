@@ -0,0 +1,45 @@
+package main
+
+// This file contains support for the constrictor ruleset, where every snake
+// grows every turn and never goes hungry. Tails never move out of the way and
+// there is no food to go after, so the game is all about space.
+//
+// See https://docs.battlesnake.com/references/game-modes#constrictor
+
+const rulesetConstrictor = "constrictor"
+
+// constrictorEvalWeights leave out food, which doesn't exist, and lean on
+// territory since every cell taken is taken for good
+var constrictorEvalWeights = evalWeights{
+	Space:     1,
+	Territory: 1.5,
+	Threat:    0.3,
+}
+
+// constrictorHeuristicWeights leave out food too and square territory
+var constrictorHeuristicWeights = heuristicWeights{
+	Food:      0,
+	Snake:     1.5,
+	Collision: 2,
+	Edge:      1.0 / 6,
+	Space:     2,
+	Safe:      0.5,
+	Territory: 2,
+	Split:     1,
+	DeadEnd:   0.1,
+}
+
+var constrictorGameMode = gameMode{
+	Name:  rulesetConstrictor,
+	Rules: rules{GrowsEveryTurn: true},
+	Strategy: strategy{
+		Eval:       constrictorEvalWeights,
+		Heuristics: constrictorHeuristicWeights,
+	},
+}
+
+// growsEveryTurn returns back whether snakes grow every turn as if they had
+// eaten, so their tails never move
+func (r Ruleset) growsEveryTurn() bool {
+	return r.rules().GrowsEveryTurn
+}
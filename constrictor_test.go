@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func constrictorState(width, height int, snakes ...Battlesnake) GameState {
+	state := newTestState(width, height, nil, snakes...)
+	state.Game.Ruleset = Ruleset{Name: rulesetConstrictor}
+	state.Board.ruleset = state.Game.Ruleset
+	return state
+}
+
+func TestConstrictorStep(t *testing.T) {
+	state := constrictorState(5, 5, newTestSnake("me", 100, Coord{1, 1}, Coord{1, 1}, Coord{1, 1}))
+
+	next := state
+	for _, m := range []BattlesnakeMove{BattlesnakeMove_Up, BattlesnakeMove_Up, BattlesnakeMove_Right} {
+		next = Step(next, map[string]BattlesnakeMove{"me": m})
+	}
+
+	assert.True(t, next.Alive("me"))
+	assert.Equal(t, []Coord{{2, 3}, {1, 3}, {1, 2}, {1, 1}, {1, 1}, {1, 1}}, next.You.Body)
+	assert.Equal(t, SnakeMaxHealth, next.You.Health)
+}
+
+func TestConstrictorOccupied(t *testing.T) {
+	me := newTestSnake("me", 100, Coord{1, 2}, Coord{1, 1}, Coord{1, 0})
+	standard := newTestState(5, 5, nil, me)
+	constrictor := constrictorState(5, 5, me)
+
+	assert.False(t, standard.Board.Occupied(Coord{1, 0}))
+	assert.True(t, constrictor.Board.Occupied(Coord{1, 0}))
+
+	bb := newBitBoard(constrictor.Board)
+	assert.True(t, bb.blocked(bb.index(Coord{1, 0})))
+}
+
+func TestConstrictorTimedFloodFill(t *testing.T) {
+	// coiled up, the tail never moves out of the way
+	state := constrictorState(3, 3,
+		newTestSnake("me", 100, Coord{1, 1}, Coord{0, 1}, Coord{0, 2}, Coord{1, 2}, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}, Coord{1, 0}),
+	)
+	space := newBitBoard(state.Board).timedFloodFill(state.You.Head, state.You.Health)
+	assert.Equal(t, openSpace{escapeTurns: -1}, space)
+	assert.True(t, space.deadEnd(len(state.You.Body)))
+}
+
+func TestConstrictorIgnoresFood(t *testing.T) {
+	state := constrictorState(5, 5, newTestSnake("me", 100, Coord{1, 1}))
+	state.Board.Food = []Coord{{1, 3}}
+	assert.Zero(t, planFood(state.You, state.Board).weight(Direction_Up))
+	assert.Equal(t, constrictorEvalWeights, gameModeFor(state.Board.Ruleset()).Strategy.Eval)
+	assert.Equal(t, defaultEvalWeights, gameModeFor(Ruleset{Name: rulesetStandard}).Strategy.Eval)
+}
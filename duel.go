@@ -0,0 +1,244 @@
+package main
+
+// This file contains the search used once only two snakes are left. Each turn is
+// a matrix of joint moves: we pick the row that maximizes our worst case over
+// the opponent's columns, pruning rows and columns with alpha-beta. Searched
+// states are kept in a transposition table across iterations.
+//
+// Assuming the opponent knows our move makes the search pessimistic, so a proven
+// win is guaranteed however the opponent plays while a proven loss only means we
+// lose against an opponent that plays perfectly.
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/go-kit/log"
+)
+
+// proof is the outcome of the game when the search saw every line to its end
+type proof string
+
+const (
+	provenWin  proof = "win"
+	provenLoss proof = "loss"
+)
+
+// provenMargin is how far from winScore and lossScore a score can be, from
+// preferring quicker wins and slower losses, and still be a proven outcome
+const provenMargin = 1000
+
+// proofOf returns back the proof a score represents, if any
+func proofOf(score float64) proof {
+	switch {
+	case score >= winScore-provenMargin:
+		return provenWin
+	case score <= lossScore+provenMargin:
+		return provenLoss
+	}
+	return ""
+}
+
+type duelSearch struct {
+	ctx     context.Context
+	logger  log.Logger
+	weights evalWeights
+	meID    string
+	themID  string
+	// rootOrder is the order our moves are tried in at the root, best first
+	rootOrder []BattlesnakeMove
+	// theirRootOrder is the order the opponent's moves are tried in at the root
+	theirRootOrder []BattlesnakeMove
+	tt             *transpositionTable
+	// rootScores are the exact scores of our moves at the root
+	rootScores map[BattlesnakeMove]float64
+
+	nodes  int
+	cutoff bool
+}
+
+// duelDepthSearch returns back a depthSearch for a state with exactly two snakes
+// where depth is the number of turns searched. Moves at the root are ordered by
+// the single-ply heuristics, with the previous iteration's best move first.
+func duelDepthSearch(logger log.Logger, state GameState, weights evalWeights) depthSearch {
+	them := otherSnakes(state.You.ID, state.Board.Snakes)[0]
+	theirState := state
+	theirState.You = them
+
+	rootOrder := orderByHeuristic(logger, state, candidateMoves(state.You, state.Board))
+	theirRootOrder := orderByHeuristic(logger, theirState, candidateMoves(them, state.Board))
+	tt := newTranspositionTable(ttSize)
+
+	return func(ctx context.Context, depth int) (searchResult, error) {
+		tt.nextGeneration()
+		s := &duelSearch{
+			ctx:            ctx,
+			logger:         logger,
+			weights:        weights,
+			meID:           state.You.ID,
+			themID:         them.ID,
+			rootOrder:      rootOrder,
+			theirRootOrder: theirRootOrder,
+			tt:             tt,
+			rootScores:     map[BattlesnakeMove]float64{},
+		}
+		score, move, err := s.max(state, depth, 0, math.Inf(-1), math.Inf(1))
+		if err != nil {
+			return searchResult{}, err
+		}
+		if move == "" {
+			move = rootOrder[0]
+		}
+		rootOrder = moveToFront(rootOrder, move)
+
+		proven := proofOf(score)
+		return searchResult{
+			move:     move,
+			score:    score,
+			depth:    depth,
+			complete: !s.cutoff || proven != "",
+			proven:   proven,
+			scores:   s.rootScores,
+			keyvals:  []interface{}{"search", "duel", "nodes", s.nodes, "tt_hits", tt.hits},
+		}, nil
+	}
+}
+
+// max returns back our best worst-case score for the state within the alpha-beta
+// window along with the move that achieves it
+func (s *duelSearch) max(state GameState, depth, ply int, alpha, beta float64) (float64, BattlesnakeMove, error) {
+	s.nodes++
+	if s.ctx.Err() != nil {
+		return 0, "", s.ctx.Err()
+	}
+	me, meAlive := findSnake(s.meID, state.Board.Snakes)
+	them, themAlive := findSnake(s.themID, state.Board.Snakes)
+	switch {
+	case !meAlive && !themAlive:
+		return drawScore + float64(ply), "", nil
+	case !meAlive:
+		return lossScore + float64(ply), "", nil
+	case !themAlive:
+		return winScore - float64(ply), "", nil
+	}
+
+	hash := zobristHash(state)
+	var ttMove BattlesnakeMove
+	if entry, ok := s.tt.lookup(hash); ok {
+		ttMove = entry.move
+		if entry.depth >= depth {
+			score := rootScore(entry.score, ply)
+			if entry.bound == ttExact ||
+				(entry.bound == ttLower && score >= beta) ||
+				(entry.bound == ttUpper && score <= alpha) {
+				s.cutoff = s.cutoff || entry.cutoff
+				return score, entry.move, nil
+			}
+		}
+	}
+
+	if depth == 0 {
+		s.cutoff = true
+		score := s.weights.evaluate(s.logger, state, s.meID) - s.weights.evaluate(s.logger, state, s.themID)
+		s.tt.store(ttEntry{hash: hash, score: score, bound: ttExact, cutoff: true})
+		return score, "", nil
+	}
+
+	myMoves, theirMoves := s.rootOrder, s.theirRootOrder
+	if ply > 0 {
+		myMoves = orderByEdges(me, state.Board, candidateMoves(me, state.Board))
+		theirMoves = orderByEdges(them, state.Board, candidateMoves(them, state.Board))
+		if ttMove != "" {
+			myMoves = moveToFront(myMoves, ttMove)
+		}
+	}
+
+	// the cut-off is tracked per state as in multiplayerSearch.turn
+	cutoffAbove := s.cutoff
+	s.cutoff = false
+	best := math.Inf(-1)
+	var bestMove BattlesnakeMove
+	moves := map[string]BattlesnakeMove{}
+	for _, m := range myMoves {
+		moves[s.meID] = m
+		bound := math.Max(alpha, best)
+		if ply == 0 {
+			// at the root a move is only cut short once it is shown to lose or
+			// draw, so the rest get exact scores to check overrides against
+			bound = math.Min(bound, drawScore+provenMargin)
+		}
+		worst := math.Inf(1)
+		for _, n := range theirMoves {
+			moves[s.themID] = n
+			score, _, err := s.max(Step(state, moves), depth-1, ply+1, bound, math.Min(beta, worst))
+			if err != nil {
+				return 0, "", err
+			}
+			worst = math.Min(worst, score)
+			if worst <= bound {
+				break
+			}
+		}
+		if ply == 0 && worst > bound {
+			s.rootScores[m] = worst
+		}
+		if worst > best || bestMove == "" {
+			best, bestMove = worst, m
+		}
+		if best >= beta {
+			break
+		}
+	}
+
+	bound := ttExact
+	if best <= alpha {
+		bound = ttUpper
+	} else if best >= beta {
+		bound = ttLower
+	}
+	s.tt.store(ttEntry{hash: hash, depth: depth, score: ttScore(best, ply), bound: bound, move: bestMove, cutoff: s.cutoff})
+	s.cutoff = s.cutoff || cutoffAbove
+	return best, bestMove, nil
+}
+
+// orderByHeuristic sorts the moves by the weights the single-ply heuristics give
+// them, best first. Moves the heuristics did not score go last.
+func orderByHeuristic(logger log.Logger, state GameState, moves []BattlesnakeMove) []BattlesnakeMove {
+	weights := map[BattlesnakeMove]float64{}
+	for _, m := range heuristicMoves(logger, state) {
+		weights[m.dir] = m.weight
+	}
+	ordered := make([]BattlesnakeMove, len(moves))
+	copy(ordered, moves)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		wi, oki := weights[ordered[i]]
+		wj, okj := weights[ordered[j]]
+		if oki != okj {
+			return oki
+		}
+		return wi > wj
+	})
+	return ordered
+}
+
+// orderByEdges sorts the moves so that the ones that keep the snake away from the
+// edges of the board go first. It is a cheap ordering for nodes below the root.
+func orderByEdges(snake Battlesnake, board Board, moves []BattlesnakeMove) []BattlesnakeMove {
+	sort.SliceStable(moves, func(i, j int) bool {
+		return edgeWeight(moveToDirection[moves[i]], snake, board) > edgeWeight(moveToDirection[moves[j]], snake, board)
+	})
+	return moves
+}
+
+// moveToFront returns back the moves with m moved to the front
+func moveToFront(moves []BattlesnakeMove, m BattlesnakeMove) []BattlesnakeMove {
+	ordered := make([]BattlesnakeMove, 0, len(moves))
+	ordered = append(ordered, m)
+	for _, other := range moves {
+		if other != m {
+			ordered = append(ordered, other)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuelSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      GameState
+		depth      int
+		wantMove   BattlesnakeMove
+		wantProven proof
+	}{
+		{
+			name: "proven win when the opponent is trapped",
+			state: newTestState(5, 5, nil,
+				newTestSnake("me", 90, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}),
+				// cornered between the walls and its own body with no moves left
+				newTestSnake("them", 90, Coord{0, 4}, Coord{1, 4}, Coord{1, 3}, Coord{0, 3}, Coord{0, 2}),
+			),
+			depth:      3,
+			wantProven: provenWin,
+		},
+		{
+			name: "proven loss when we are trapped",
+			state: newTestState(5, 5, nil,
+				newTestSnake("me", 90, Coord{0, 4}, Coord{1, 4}, Coord{1, 3}, Coord{0, 3}, Coord{0, 2}),
+				newTestSnake("them", 90, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}),
+			),
+			depth:      3,
+			wantProven: provenLoss,
+		},
+		{
+			name: "wins the head to head against a shorter snake",
+			state: newTestState(7, 7, nil,
+				newTestSnake("me", 90, Coord{1, 3}, Coord{2, 3}, Coord{3, 3}, Coord{4, 3}, Coord{5, 3}, Coord{6, 3}),
+				// its only move is up into {0, 3}, where we meet it as the longer snake
+				newTestSnake("them", 90, Coord{0, 2}, Coord{1, 2}, Coord{1, 1}, Coord{0, 1}, Coord{0, 0}),
+			),
+			depth:      1,
+			wantMove:   BattlesnakeMove_Left,
+			wantProven: provenWin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := duelDepthSearch(log.NewNopLogger(), tt.state, defaultEvalWeights)
+			result, err := search(context.Background(), tt.depth)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantProven, result.proven)
+			if tt.wantProven != "" {
+				assert.True(t, result.complete)
+			}
+			if tt.wantMove != "" {
+				assert.Equal(t, tt.wantMove, result.move)
+			}
+			assert.Equal(t, result.score, result.scores[result.move])
+			assert.Equal(t, tt.wantProven != provenLoss, result.survives(result.move))
+		})
+	}
+}
+
+func TestProofOf(t *testing.T) {
+	assert.Equal(t, provenWin, proofOf(winScore-3))
+	assert.Equal(t, provenLoss, proofOf(lossScore+3))
+	assert.Equal(t, proof(""), proofOf(drawScore+3))
+	assert.Equal(t, proof(""), proofOf(0.5))
+}
@@ -0,0 +1,116 @@
+package main
+
+// This file contains the leaf evaluator used by the tree searches. It reuses the
+// same terms as the single-ply heuristics in logic.go.
+
+import (
+	"math"
+
+	"github.com/go-kit/log"
+)
+
+const (
+	// winScore is the score of a state where every opponent has been eliminated
+	winScore = 1e6
+	// lossScore is the score of a state where the snake has been eliminated
+	lossScore = -winScore
+	// drawScore is the score of a state where every snake, including this one, has
+	// been eliminated
+	drawScore = lossScore / 2
+)
+
+// evalWeights are the weights of the heuristic terms combined by evaluate
+type evalWeights struct {
+	Space     float64
+	Territory float64
+	Food      float64
+	Threat    float64
+	Length    float64
+	// Aggression is for closing in on shorter snakes and keeping away from the
+	// rest, which territory alone can't tell apart from running away when both
+	// win about the same cells
+	Aggression float64
+}
+
+var defaultEvalWeights = evalWeights{
+	Space:      1,
+	Territory:  0.5,
+	Food:       0.5,
+	Threat:     0.3,
+	Length:     0.2,
+	Aggression: 0.2,
+}
+
+// evaluate scores the state from the perspective of the snake with the given ID.
+// Eliminated snakes get lossScore, otherwise the score is a weighted sum of:
+//
+//	space: the fraction of the free board reachable from the snake's head
+//	territory: the fraction of the free board the snake's squad reaches before anyone else
+//	food: how close the nearest food the snake wins the race to is, scaled by hunger
+//	threat: how clear of longer snakes the snake is, averaged over directions
+//	length: how much longer the snake is than its average opponent
+//	aggression: how much closer the snake's head is to the closest shorter
+//	opponent's than to the closest one at least as long
+//
+// Squadmates count as one team: their territory adds up and they aren't threats.
+func (w evalWeights) evaluate(logger log.Logger, state GameState, id string) float64 {
+	me, ok := findSnake(id, state.Board.Snakes)
+	if !ok {
+		return lossScore
+	}
+
+	freeSpaces := state.Board.Height * state.Board.Width
+	for _, snake := range state.Board.Snakes {
+		freeSpaces -= len(snake.Body)
+	}
+	space, territory := 0.0, 0.0
+	if freeSpaces > 0 {
+		space = float64(numOpenSpaces(logger, me, state.Board)) / float64(freeSpaces)
+		territories := voronoi(state.Board).territories
+		for _, snake := range state.Board.team(id) {
+			territory += float64(territories[snake.ID].cells)
+		}
+		territory /= float64(freeSpaces)
+	}
+
+	food := state.foodField().closeness(me, state.Board)
+	threat := 0.0
+	for _, dir := range directions {
+		threat += otherSnakeWeight(state.Board.comparator(dir), me, state.Board)
+	}
+	threat /= float64(len(directions))
+	hunger := 1 - float64(me.Health)/float64(SnakeMaxHealth)
+
+	lengthLead, prey, predator := 0.0, 0.0, 0.0
+	span := state.Board.Topology().Span()
+	others := state.Board.opponents(id)
+	for _, other := range others {
+		lengthLead += float64(me.Length - other.Length)
+		closeness := 1 - float64(state.Board.Manhattan(me.Head, other.Head))/float64(span.X+span.Y)
+		if other.Length < me.Length {
+			prey = math.Max(prey, closeness)
+		} else {
+			predator = math.Max(predator, closeness)
+		}
+	}
+	if len(others) > 0 {
+		lengthLead /= float64(len(others))
+	}
+
+	return w.Space*space +
+		w.Territory*territory +
+		w.Food*hunger*food +
+		w.Threat*threat +
+		w.Length*math.Tanh(lengthLead/4) +
+		w.Aggression*(prey-predator)
+}
+
+// findSnake returns back the snake with the given ID and whether it was found
+func findSnake(id string, snakes []Battlesnake) (Battlesnake, bool) {
+	for _, snake := range snakes {
+		if snake.ID == id {
+			return snake, true
+		}
+	}
+	return Battlesnake{}, false
+}
@@ -0,0 +1,192 @@
+package main
+
+// This file contains the registry of game modes. Every ruleset the engine can run
+// is a game mode made up of the rules that set it apart from the standard game
+// and the strategy that suits it. The mode of a game is looked up by the name and
+// version of its ruleset, so a new mode only has to be registered here for the
+// rules engine and move to pick it up.
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rulesetStandard = "standard"
+	rulesetSolo     = "solo"
+)
+
+// rules are the parts of the rules engine that differ between game modes
+type rules struct {
+	// Wrapped boards join up at the edges, see topology.go
+	Wrapped bool
+	// GrowsEveryTurn has snakes grow every turn and never go hungry, see
+	// constrictor.go
+	GrowsEveryTurn bool
+	// Squads has snakes play in teams, see squad.go
+	Squads bool
+	// Shrinks has the safe zone shrink every few turns, see royale.go
+	Shrinks bool
+}
+
+// heuristicWeights are the exponents heuristicMoves raises each of its terms to,
+// so the larger the weight the more the term matters
+type heuristicWeights struct {
+	// Food is scaled by how far behind the average opponent we are
+	Food      float64
+	Snake     float64
+	Collision float64
+	// Edge is scaled by the square root of the turn so the edges matter more as
+	// the board fills up
+	Edge      float64
+	Space     float64
+	Safe      float64
+	Territory float64
+	// Split is for the share of the free cells still in reach after moving onto
+	// a cell that splits them, see chokepoint.go
+	Split float64
+	// DeadEnd multiplies rather than raises moves into a dead end
+	DeadEnd float64
+}
+
+var defaultHeuristicWeights = heuristicWeights{
+	Food:      0.5,
+	Snake:     1.5,
+	Collision: 2,
+	Edge:      1.0 / 6,
+	Space:     2,
+	Safe:      0.5,
+	Territory: 0.5,
+	Split:     1,
+	DeadEnd:   0.1,
+}
+
+// strategy is how the snake plays a game mode
+type strategy struct {
+	Eval       evalWeights
+	Heuristics heuristicWeights
+	// Algorithm replaces the configured search algorithm when set
+	Algorithm searchAlgorithm
+	// LatencyMargin replaces the configured latency margin when set
+	LatencyMargin time.Duration
+}
+
+var defaultStrategy = strategy{
+	Eval:       defaultEvalWeights,
+	Heuristics: defaultHeuristicWeights,
+}
+
+// configure returns back the search configuration with the strategy's choices
+// in place of the configured ones
+func (s strategy) configure(config searchConfig) searchConfig {
+	if s.Algorithm != "" {
+		config.Algorithm = s.Algorithm
+	}
+	if s.LatencyMargin > 0 {
+		config.LatencyMargin = s.LatencyMargin
+	}
+	return config
+}
+
+type gameMode struct {
+	// Name is the name of the ruleset
+	Name string
+	// Version is the version of the ruleset, or empty to match any version
+	Version  string
+	Rules    rules
+	Strategy strategy
+}
+
+var (
+	gameModesMu sync.RWMutex
+	// gameModes are keyed by name and then version
+	gameModes = map[string]map[string]gameMode{}
+	// gameModesGeneration counts the registrations so lookups made before one
+	// can be told apart
+	gameModesGeneration int64
+	// lastGameMode is the most recent lookup. The rules are looked up on every
+	// move made during a search, nearly always for the same ruleset.
+	lastGameMode atomic.Value
+)
+
+type gameModeLookup struct {
+	name, version string
+	generation    int64
+	mode          gameMode
+}
+
+func init() {
+	for _, mode := range []gameMode{
+		{Name: rulesetStandard, Strategy: defaultStrategy},
+		{Name: rulesetSolo, Strategy: defaultStrategy},
+		royaleGameMode,
+		wrappedGameMode,
+		constrictorGameMode,
+		squadGameMode,
+	} {
+		registerGameMode(mode)
+	}
+}
+
+// registerGameMode adds the mode to the registry, replacing any mode with the
+// same name and version
+func registerGameMode(mode gameMode) {
+	gameModesMu.Lock()
+	defer gameModesMu.Unlock()
+	if gameModes[mode.Name] == nil {
+		gameModes[mode.Name] = map[string]gameMode{}
+	}
+	gameModes[mode.Name][mode.Version] = mode
+	atomic.AddInt64(&gameModesGeneration, 1)
+}
+
+// gameModeFor returns back the mode registered for the exact version of the
+// ruleset, falling back to the one for any version of it and then to the
+// standard game
+func gameModeFor(ruleset Ruleset) gameMode {
+	return cachedGameMode(ruleset).mode
+}
+
+func cachedGameMode(ruleset Ruleset) *gameModeLookup {
+	last, ok := lastGameMode.Load().(*gameModeLookup)
+	if ok && last.name == ruleset.Name && last.version == ruleset.Version &&
+		last.generation == atomic.LoadInt64(&gameModesGeneration) {
+		return last
+	}
+	last = lookupGameMode(ruleset)
+	lastGameMode.Store(last)
+	return last
+}
+
+func lookupGameMode(ruleset Ruleset) *gameModeLookup {
+	gameModesMu.RLock()
+	defer gameModesMu.RUnlock()
+	return &gameModeLookup{
+		name:       ruleset.Name,
+		version:    ruleset.Version,
+		generation: atomic.LoadInt64(&gameModesGeneration),
+		mode:       registeredGameMode(ruleset),
+	}
+}
+
+// registeredGameMode returns back the registered mode of the ruleset, the
+// registry has to be locked
+func registeredGameMode(ruleset Ruleset) gameMode {
+	versions, ok := gameModes[ruleset.Name]
+	if !ok {
+		versions = gameModes[rulesetStandard]
+	}
+	if mode, ok := versions[ruleset.Version]; ok {
+		return mode
+	}
+	if mode, ok := versions[""]; ok {
+		return mode
+	}
+	return gameMode{Name: rulesetStandard, Strategy: defaultStrategy}
+}
+
+// rules returns back the rules of the ruleset's game mode
+func (r Ruleset) rules() rules {
+	return cachedGameMode(r).mode.Rules
+}
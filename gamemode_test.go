@@ -0,0 +1,96 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withGameMode registers the mode for as long as the test runs, returning back
+// a function that removes it again
+func withGameMode(mode gameMode) func() {
+	registerGameMode(mode)
+	return func() {
+		gameModesMu.Lock()
+		defer gameModesMu.Unlock()
+		delete(gameModes[mode.Name], mode.Version)
+		if len(gameModes[mode.Name]) == 0 {
+			delete(gameModes, mode.Name)
+		}
+		atomic.AddInt64(&gameModesGeneration, 1)
+	}
+}
+
+func TestGameModeFor(t *testing.T) {
+	defer withGameMode(gameMode{Name: rulesetRoyale, Version: "v2.0.0", Rules: rules{Shrinks: true, Wrapped: true}})()
+
+	tests := []struct {
+		name        string
+		ruleset     Ruleset
+		wantName    string
+		wantVersion string
+		wantRules   rules
+	}{
+		{name: "standard", ruleset: Ruleset{Name: rulesetStandard, Version: "v1.0.0"}, wantName: rulesetStandard},
+		{name: "unknown ruleset plays standard", ruleset: Ruleset{Name: "made-up"}, wantName: rulesetStandard},
+		{name: "no ruleset plays standard", wantName: rulesetStandard},
+		{name: "any version", ruleset: Ruleset{Name: rulesetRoyale, Version: "v1.0.0"}, wantName: rulesetRoyale, wantRules: rules{Shrinks: true}},
+		{name: "exact version", ruleset: Ruleset{Name: rulesetRoyale, Version: "v2.0.0"}, wantName: rulesetRoyale, wantVersion: "v2.0.0", wantRules: rules{Shrinks: true, Wrapped: true}},
+		{name: "wrapped", ruleset: Ruleset{Name: rulesetWrapped}, wantName: rulesetWrapped, wantRules: rules{Wrapped: true}},
+		{name: "constrictor", ruleset: Ruleset{Name: rulesetConstrictor}, wantName: rulesetConstrictor, wantRules: rules{GrowsEveryTurn: true}},
+		{name: "squad", ruleset: Ruleset{Name: rulesetSquad}, wantName: rulesetSquad, wantRules: rules{Squads: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := gameModeFor(tt.ruleset)
+			assert.Equal(t, tt.wantName, mode.Name)
+			assert.Equal(t, tt.wantVersion, mode.Version)
+			assert.Equal(t, tt.wantRules, mode.Rules)
+			assert.Equal(t, tt.wantRules, tt.ruleset.rules())
+		})
+	}
+}
+
+func TestRegisterGameMode(t *testing.T) {
+	ruleset := Ruleset{Name: "wrapped-constrictor"}
+	board := Board{Width: 5, Height: 5, ruleset: ruleset}
+	assert.IsType(t, planarTopology{}, board.Topology())
+	assert.False(t, ruleset.growsEveryTurn())
+
+	// a mode registered after the ruleset was looked up takes over from the
+	// standard game it fell back to
+	defer withGameMode(gameMode{
+		Name:     "wrapped-constrictor",
+		Rules:    rules{Wrapped: true, GrowsEveryTurn: true},
+		Strategy: constrictorGameMode.Strategy,
+	})()
+	assert.IsType(t, wrappedTopology{}, board.Topology())
+	assert.True(t, ruleset.growsEveryTurn())
+	assert.Equal(t, constrictorEvalWeights, gameModeFor(ruleset).Strategy.Eval)
+}
+
+func TestStrategyConfigure(t *testing.T) {
+	config := searchConfig{
+		Algorithm:     algorithmParanoid,
+		LatencyMargin: 150 * time.Millisecond,
+		MaxDepth:      32,
+		RolloutPolicy: rolloutFloodFill,
+	}
+	assert.Equal(t, config, defaultStrategy.configure(config))
+
+	s := strategy{Algorithm: algorithmMCTS, LatencyMargin: 50 * time.Millisecond}
+	want := config
+	want.Algorithm = algorithmMCTS
+	want.LatencyMargin = 50 * time.Millisecond
+	assert.Equal(t, want, s.configure(config))
+}
+
+func TestConstrictorHeuristicWeights(t *testing.T) {
+	// constrictor only differs from the default weights by its food and territory
+	want := defaultHeuristicWeights
+	want.Food = 0
+	want.Territory = 2
+	assert.Equal(t, want, constrictorHeuristicWeights)
+}
@@ -0,0 +1,84 @@
+package main
+
+// This file contains the head-to-head risk model. When snakes move their heads
+// onto the same cell the shorter ones are eliminated, or all of them if none is
+// longer. Whether an opponent moves onto the cell we are moving to is a guess:
+// the opponent model gives the odds of each of its safe moves, so an opponent
+// with no other way to go takes the cell for sure and one with plenty of
+// alternatives probably doesn't.
+
+const (
+	// headToHeadKillBonus is how much eliminating a shorter snake head-on is
+	// worth on top of surviving the turn
+	headToHeadKillBonus = 0.5
+	// headToHeadDrawValue is what being eliminated along with our last opponent
+	// is worth, since the game is then a draw rather than a loss
+	headToHeadDrawValue = 0.5
+)
+
+// headToHead are the chances of what happens when our head moves onto a cell,
+// assuming the opponents move independently
+type headToHead struct {
+	// survive is the chance of no snake at least as long moving onto the cell
+	survive float64
+	// trade is the chance of an equal length snake moving onto the cell, but no
+	// longer one, so we are eliminated along with it
+	trade float64
+	// die is the chance of a longer snake moving onto the cell
+	die float64
+	// kill is the chance of surviving while a shorter snake moves onto the cell
+	// and is eliminated
+	kill float64
+	// lastOpponent is set when a trade would leave no opponent standing
+	lastOpponent bool
+}
+
+// headToHeadRisk returns back the chances of what happens when our snake moves
+// its head in the direction. Lengths are compared after the move, once whoever
+// moves onto food has eaten it.
+func headToHeadRisk(me Battlesnake, dir Direction, board Board, models opponentModels) headToHead {
+	myNext := me.Advance(dir, board)
+	next := myNext.Head
+	// the chance of no snake of each kind moving onto the cell
+	missLonger, missEqual, missShorter := 1.0, 1.0, 1.0
+	opponents := board.opponents(me.ID)
+	for _, snake := range opponents {
+		if board.Manhattan(snake.Head, next) != 1 {
+			continue
+		}
+		p := 0.0
+		length := snake.Length
+		for otherDir, odds := range models[snake.ID].predict(snake, board, me.Head) {
+			if board.Topology().Move(snake.Head, otherDir) == next {
+				p += odds
+				length = snake.Advance(otherDir, board).Length
+			}
+		}
+		switch {
+		case length > myNext.Length:
+			missLonger *= 1 - p
+		case length == myNext.Length:
+			missEqual *= 1 - p
+		default:
+			missShorter *= 1 - p
+		}
+	}
+	h := headToHead{
+		survive:      missLonger * missEqual,
+		trade:        missLonger * (1 - missEqual),
+		die:          1 - missLonger,
+		lastOpponent: len(opponents) == 1,
+	}
+	h.kill = h.survive * (1 - missShorter)
+	return h
+}
+
+// expected returns back the expected outcome of the move, 1 for surviving and 0
+// for being eliminated, with a bonus for eliminating a shorter snake
+func (h headToHead) expected() float64 {
+	value := h.survive + headToHeadKillBonus*h.kill
+	if h.lastOpponent {
+		value += headToHeadDrawValue * h.trade
+	}
+	return value
+}
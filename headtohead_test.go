@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadToHeadRisk(t *testing.T) {
+	me := newTestSnake("me", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1})
+	// we move onto (4, 3)
+	dir := Direction_Right
+	// opponents of each length with their head to the right of the cell
+	opponent := func(length int) Battlesnake {
+		body := []Coord{}
+		for i := 0; i < length; i++ {
+			body = append(body, Coord{5, 3 - i})
+		}
+		return newTestSnake("them", 90, body...)
+	}
+	far := newTestSnake("far", 90, Coord{0, 6}, Coord{1, 6}, Coord{2, 6})
+	// boxes the longer opponent in so the cell is its only way out
+	box := newTestSnake("box", 90, Coord{6, 6}, Coord{6, 5}, Coord{6, 4}, Coord{6, 3}, Coord{6, 2}, Coord{5, 4}, Coord{5, 5})
+
+	tests := []struct {
+		name    string
+		snakes  []Battlesnake
+		food    []Coord
+		ruleset Ruleset
+		want    headToHead
+	}{
+		{
+			name:   "nobody nearby",
+			snakes: []Battlesnake{me, far},
+			want:   headToHead{survive: 1, lastOpponent: true},
+		},
+		{
+			name:   "longer snake with alternatives",
+			snakes: []Battlesnake{me, opponent(4), far},
+			want:   headToHead{survive: 2.0 / 3, die: 1.0 / 3},
+		},
+		{
+			name:   "longer snake with no alternative",
+			snakes: []Battlesnake{me, opponent(4), box},
+			want:   headToHead{die: 1},
+		},
+		{
+			name:   "equal snake is a trade",
+			snakes: []Battlesnake{me, opponent(3), far},
+			want:   headToHead{survive: 2.0 / 3, trade: 1.0 / 3},
+		},
+		{
+			name:   "equal last opponent is a draw",
+			snakes: []Battlesnake{me, opponent(3)},
+			want:   headToHead{survive: 2.0 / 3, trade: 1.0 / 3, lastOpponent: true},
+		},
+		{
+			name:   "shorter snake can be eliminated",
+			snakes: []Battlesnake{me, opponent(2), far},
+			want:   headToHead{survive: 1, kill: 1.0 / 3},
+		},
+		{
+			name:   "food on the cell feeds both of us",
+			snakes: []Battlesnake{me, opponent(3), far},
+			food:   []Coord{{4, 3}},
+			want:   headToHead{survive: 2.0 / 3, trade: 1.0 / 3},
+		},
+		{
+			name:   "food on the cell leaves a longer snake longer",
+			snakes: []Battlesnake{me, opponent(4), far},
+			food:   []Coord{{4, 3}},
+			want:   headToHead{survive: 2.0 / 3, die: 1.0 / 3},
+		},
+		{
+			name: "squadmates are no risk",
+			snakes: []Battlesnake{
+				squadSnake("me", "red", 90, me.Body...),
+				squadSnake("them", "red", 90, opponent(4).Body...),
+				squadSnake("far", "blue", 90, far.Body...),
+			},
+			ruleset: squadRuleset(SquadSettings{}),
+			want:    headToHead{survive: 1, lastOpponent: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := Board{Width: 7, Height: 7, Snakes: tt.snakes, Food: tt.food, ruleset: tt.ruleset}
+			got := headToHeadRisk(tt.snakes[0], dir, board, nil)
+			assert.InDelta(t, tt.want.survive, got.survive, 1e-9, "survive")
+			assert.InDelta(t, tt.want.trade, got.trade, 1e-9, "trade")
+			assert.InDelta(t, tt.want.die, got.die, 1e-9, "die")
+			assert.InDelta(t, tt.want.kill, got.kill, 1e-9, "kill")
+			assert.Equal(t, tt.want.lastOpponent, got.lastOpponent)
+		})
+	}
+}
+
+func TestHeadToHeadExpected(t *testing.T) {
+	tests := []struct {
+		name string
+		h    headToHead
+		want float64
+	}{
+		{name: "safe", h: headToHead{survive: 1}, want: 1},
+		{name: "certain death", h: headToHead{die: 1}, want: 0},
+		{name: "trade", h: headToHead{survive: 0.5, trade: 0.5}, want: 0.5},
+		{name: "trade with the last opponent", h: headToHead{survive: 0.5, trade: 0.5, lastOpponent: true}, want: 0.5 + headToHeadDrawValue*0.5},
+		{name: "kill", h: headToHead{survive: 1, kill: 0.5}, want: 1 + headToHeadKillBonus*0.5},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, tt.h.expected(), 1e-9, tt.name)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+// This file contains the last resort for when no move is any good. Every move is
+// ranked by how many turns the snake can expect to survive after it: none for
+// leaving the board, running into a body or starving, and otherwise as many as
+// the room it is left with allows, up to its length, times the chance of coming
+// out of the head-to-heads the move risks. A head-to-head that may not happen
+// beats running into a wall. Ties are broken by a random number generator so
+// that the same seed always makes the same choice and losses can be replayed.
+
+import (
+	"math/rand"
+)
+
+// leastBadMove returns back the move the snake can expect to survive the most
+// turns after, breaking ties with the generator
+func leastBadMove(state GameState, rng *rand.Rand) BattlesnakeMove {
+	best, bestTurns, ties := BattlesnakeMove(""), -1.0, 0
+	for _, dir := range directions {
+		turns := expectedTurns(state, dir)
+		switch {
+		case turns > bestTurns:
+			best, bestTurns, ties = directionToMove[dir], turns, 1
+		case turns == bestTurns:
+			ties++
+			if rng.Intn(ties) == 0 {
+				best = directionToMove[dir]
+			}
+		}
+	}
+	return best
+}
+
+// expectedTurns returns back how many turns the snake can expect to survive after
+// moving in the direction. Being eliminated along with our last opponent is a
+// draw, which counts for part of surviving.
+func expectedTurns(state GameState, dir Direction) float64 {
+	me := state.You
+	if len(me.Body) == 0 {
+		return 0
+	}
+	next := me.Advance(dir, state.Board)
+	if state.Board.OutOfBounds(next.Head) || state.Board.Occupied(next.Head) || next.Health <= 0 {
+		return 0
+	}
+	h := headToHeadRisk(me, dir, state.Board, state.opponents)
+	chance := h.survive
+	if h.lastOpponent {
+		chance += headToHeadDrawValue * h.trade
+	}
+	turns := len(next.Body)
+	if room := openSpaceOf(next, state.Board); room.deadEnd(len(next.Body)) {
+		turns = room.area
+	}
+	return chance * float64(1+turns)
+}
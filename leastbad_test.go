@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeastBadMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		state GameState
+		want  BattlesnakeMove
+	}{
+		{
+			// the opponent may just as well go right
+			name: "possible head-on over the walls",
+			state: newTestState(5, 5, nil,
+				newTestSnake("me", 100, Coord{0, 0}, Coord{0, 1}, Coord{0, 2}),
+				newTestSnake("other", 100, Coord{2, 0}, Coord{2, 1}, Coord{2, 2}),
+			),
+			want: BattlesnakeMove_Right,
+		},
+		{
+			// the corner on the right is a dead end while the tail on the left
+			// keeps moving out of the way
+			name: "tail over a dead end",
+			state: newTestState(4, 3, nil, newTestSnake("me", 100,
+				Coord{2, 0}, Coord{2, 1}, Coord{3, 1}, Coord{3, 2}, Coord{2, 2},
+				Coord{1, 2}, Coord{0, 2}, Coord{0, 1}, Coord{1, 1}, Coord{1, 0})),
+			want: BattlesnakeMove_Left,
+		},
+		{
+			// each opponent's only move is onto the cell next to us on its side
+			name: "head-on with a shorter snake over one as long",
+			state: newTestState(5, 5, nil,
+				newTestSnake("me", 100, Coord{2, 0}, Coord{2, 1}, Coord{2, 2}),
+				newTestSnake("equal", 100, Coord{0, 0}, Coord{0, 1}, Coord{0, 2}),
+				newTestSnake("shorter", 100, Coord{4, 0}, Coord{4, 1}),
+			),
+			want: BattlesnakeMove_Right,
+		},
+		{
+			name: "head-on with a shorter snake over a longer one",
+			state: newTestState(5, 5, nil,
+				newTestSnake("me", 100, Coord{2, 0}, Coord{2, 1}, Coord{2, 2}),
+				newTestSnake("shorter", 100, Coord{0, 0}, Coord{0, 1}),
+				newTestSnake("longer", 100, Coord{4, 0}, Coord{4, 1}, Coord{4, 2}, Coord{4, 3}),
+			),
+			want: BattlesnakeMove_Left,
+		},
+		{
+			name:  "starving",
+			state: newTestState(3, 3, []Coord{{0, 1}}, newTestSnake("me", 1, Coord{1, 1}, Coord{2, 1})),
+			want:  BattlesnakeMove_Left,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, leastBadMove(tt.state, rand.New(rand.NewSource(seedFor(tt.state)))))
+		})
+	}
+}
+
+func TestLeastBadMoveIsSeeded(t *testing.T) {
+	// every move is certain death so the seed picks
+	state := newTestState(1, 1, nil, newTestSnake("me", 100, Coord{0, 0}))
+	seen := map[BattlesnakeMove]bool{}
+	for seed := int64(0); seed < 20; seed++ {
+		move := leastBadMove(state, rand.New(rand.NewSource(seed)))
+		assert.Equal(t, move, leastBadMove(state, rand.New(rand.NewSource(seed))), "seed %d", seed)
+		seen[move] = true
+	}
+	assert.Greater(t, len(seen), 1)
+}
+
+func TestPickMove(t *testing.T) {
+	state := newTestState(4, 3, nil, newTestSnake("me", 100,
+		Coord{2, 0}, Coord{2, 1}, Coord{3, 1}, Coord{3, 2}, Coord{2, 2},
+		Coord{1, 2}, Coord{0, 2}, Coord{0, 1}, Coord{1, 1}, Coord{1, 0}))
+	tests := []struct {
+		name  string
+		moves []*pMove
+		want  BattlesnakeMove
+	}{
+		{
+			name:  "best viable move",
+			moves: []*pMove{{dir: BattlesnakeMove_Right, weight: 0.5}, {dir: BattlesnakeMove_Left, weight: 0.2}},
+			want:  BattlesnakeMove_Right,
+		},
+		{
+			name:  "no viable move",
+			moves: []*pMove{{dir: BattlesnakeMove_Right}, {dir: BattlesnakeMove_Left}},
+			want:  BattlesnakeMove_Left,
+		},
+		{
+			name:  "no moves at all",
+			moves: []*pMove{},
+			want:  BattlesnakeMove_Left,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(seedFor(state)))
+			assert.Equal(t, tt.want, pickMove(log.NewNopLogger(), rng, state, tt.moves).dir)
+		})
+	}
+}
@@ -6,6 +6,7 @@ package main
 // from the list of possible moves!
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"sort"
@@ -34,117 +35,47 @@ func info() BattlesnakeInfoResponse {
 
 // This function is called everytime your Battlesnake is entered into a game.
 // The provided GameState contains information about the game that's about to be played.
-// It's purely for informational purposes, you don't have to make any decisions here.
+// It starts the session that remembers the game between moves.
 func start(state GameState) {
-	_ = level.Debug(state.Logger(logging.GlobalLogger())).Log("msg", "START")
+	sessions.start(sessionKeyOf(state))
+	_ = level.Debug(state.Logger(logging.GlobalLogger())).Log("msg", "START", "sessions", sessions.len())
 }
 
 // This function is called when a game your Battlesnake was in has ended.
-// It's purely for informational purposes, you don't have to make any decisions here.
+// It ends the session of the game.
 func end(state GameState) {
-	_ = level.Debug(state.Logger(logging.GlobalLogger())).Log("msg", "END")
-}
-
-func nextBody(move Coord, body []Coord, board Board) []Coord {
-	next := make([]Coord, len(body))
-	next[0] = body[0].Add(move)
-	for i, coord := range body[0 : len(body)-1] {
-		next[i+1] = coord
-	}
-	if CoordSliceContains(next[0], board.Food) {
-		next = append(next, body[len(body)-1])
+	keyvals := []interface{}{"msg", "END"}
+	if sess, ok := sessions.end(sessionKeyOf(state)); ok {
+		keyvals = append(keyvals, "moves", len(sess.moves), "session_ms", time.Since(sess.started).Milliseconds())
 	}
-	return next
-}
-
-func headOnCollision(me, other []Coord) bool {
-	return me[0] == other[0]
+	_ = level.Debug(state.Logger(logging.GlobalLogger())).Log(keyvals...)
 }
 
 func bodyCollision(me, other []Coord) bool {
 	return CoordSliceContains(me[0], other)
 }
 
-func dist(c1, c2 Coord) float64 {
-	return math.Abs(float64(c1.X-c2.X)) + math.Abs(float64(c1.Y-c2.Y))
-}
-
-func numOpenSpaces(logger log.Logger, body []Coord, board Board) int {
-	set := map[Coord]bool{}
-
-	isOccupied := func(target Coord) bool {
-		return board.OutOfBounds(target) ||
-			board.Occupied(target)
-	}
-
-	var recurse func(target Coord)
-	recurse = func(target Coord) {
-		if _, done := set[target]; done || (target != body[0] && isOccupied(target)) {
-			return
-		}
-		set[target] = true
-
-		recurse(Coord{target.X + 1, target.Y})
-		recurse(Coord{target.X - 1, target.Y})
-		recurse(Coord{target.X, target.Y + 1})
-		recurse(Coord{target.X, target.Y - 1})
-	}
-
-	recurse(body[0])
-
-	return len(set) - 1
+// numOpenSpaces returns back how many cells the snake's head can reach on the
+// board, counting cells that bodies move out of before the snake gets there
+func numOpenSpaces(logger log.Logger, snake Battlesnake, board Board) int {
+	return openSpaceOf(snake, board).area
 }
 
-var comparator = map[Direction]func(c1, c2 Coord) bool{
-	Direction_Up: func(c1, c2 Coord) bool {
-		return c1.Y > c2.Y
-	},
-	Direction_Down: func(c1, c2 Coord) bool {
-		return c1.Y < c2.Y
-	},
-	Direction_Left: func(c1, c2 Coord) bool {
-		return c1.X < c2.X
-	},
-	Direction_Right: func(c1, c2 Coord) bool {
-		return c1.X > c2.X
-	},
-}
-
-// foodWeight should return a floating point number indicative of
-// food availability
-func foodWeight(inDirection func(Coord, Coord) bool, head Coord, board Board) float64 {
-	count := 0
-	distAway := 0.0 // steps * food
-	for _, food := range board.Food {
-		if inDirection(food, head) {
-			count++
-			distAway += math.Pow(float64(head.Manhattan(food)), 2)
-		}
-	}
-
-	totalStepsAcrossBoard := Coord{0, 0}.Manhattan(Coord{board.Width, board.Height})
-
-	if count == 0 || len(board.Food) == 0 {
-		return 0
-	}
-
-	avgDistAway := distAway / float64(count) // steps^2
-
-	componentAvgDistAway := 1 - (avgDistAway / math.Pow(float64(totalStepsAcrossBoard), 2)) // no units
-	foodRatio := (float64(count)) / float64(len(board.Food))                                // no units
-
-	return componentAvgDistAway * foodRatio
+// openSpaceOf returns back the timed flood fill from the snake's head with the
+// snake in place of the one with its ID on the board
+func openSpaceOf(snake Battlesnake, board Board) openSpace {
+	return newBitBoard(withSnake(board, snake)).timedFloodFill(snake.Head, snake.Health)
 }
 
 func otherSnakeWeight(inDirection func(Coord, Coord) bool, me Battlesnake, board Board) float64 {
 	head := me.Head
 	count := 0
 	distAway := 0.0
-	for _, snake := range otherSnakes(me.ID, board.Snakes) {
+	for _, snake := range board.opponents(me.ID) {
 		if inDirection(snake.Head, head) {
 			if snake.Length >= me.Length {
 				count++
-				distAway += dist(head, snake.Head)
+				distAway += float64(board.Manhattan(head, snake.Head))
 			} else {
 				_ = level.Debug(logging.GlobalLogger()).Log("msg", "snake is shorter and in this direction... KILL THEM", "other_snake", snake.ID, "their_length", snake.Length, "snake_id", me.ID, "my_length", me.Length)
 			}
@@ -155,7 +86,8 @@ func otherSnakeWeight(inDirection func(Coord, Coord) bool, me Battlesnake, board
 	}
 	avgDistAway := distAway / float64(count)
 
-	return (avgDistAway / dist(Coord{0, 0}, Coord{board.Height, board.Width})) / float64(count)
+	span := board.Topology().Span()
+	return (avgDistAway / float64(span.X+span.Y)) / float64(count)
 }
 
 func otherSnakes(myID string, snakes []Battlesnake) []Battlesnake {
@@ -171,42 +103,61 @@ func otherSnakes(myID string, snakes []Battlesnake) []Battlesnake {
 	return otherSnakes
 }
 
+// withSnake returns back a copy of the board with the snake of the same ID
+// replaced by the given one
+func withSnake(board Board, snake Battlesnake) Board {
+	snakes := make([]Battlesnake, len(board.Snakes))
+	for i, other := range board.Snakes {
+		if other.ID == snake.ID {
+			other = snake
+		}
+		snakes[i] = other
+	}
+	board.Snakes = snakes
+	return board
+}
+
 type pMove struct {
 	dir    BattlesnakeMove
 	weight float64
 }
 
-func collisionWeight(logger log.Logger, dir Direction, me Battlesnake, board Board) float64 {
-	weight := 1.0
-	myNextBody := me.Next(dir, board)
+// collisionWeight is 0 for a move that could run into a body and otherwise the
+// expected outcome of the head-to-head collisions the move risks, see
+// headToHeadRisk
+func collisionWeight(logger log.Logger, dir Direction, me Battlesnake, board Board, models opponentModels) float64 {
+	myNext := me.Advance(dir, board)
 	for _, snake := range otherSnakes(me.ID, board.Snakes) {
+		// squadmates look out for us and may be fine to run through
+		passable := board.ruleset.squadmates(me, snake) && board.ruleset.Settings.Squad.AllowBodyCollisions
 		for _, otherDir := range snake.Moves(logger) {
-			nextSnake := snake.Next(otherDir, board)
-			if headOnCollision(myNextBody, nextSnake) && me.Length < snake.Length {
-				weight *= 1.0 / 3
-			}
-			if bodyCollision(myNextBody, nextSnake) {
+			nextSnake := snake.Advance(otherDir, board)
+			if !passable && bodyCollision(myNext.Body, nextSnake.Body[1:]) {
 				return 0
 			}
 		}
 	}
-	return weight
+	return headToHeadRisk(me, dir, board, models).expected()
 }
 
+// edgeWeight is higher the further from the edges of the safe zone the move
+// takes the snake, which without hazards are the edges of the board. A wrapped
+// board has no edges so every move weighs the same.
 func edgeWeight(dir Direction, me Battlesnake, board Board) float64 {
-	nextHead := me.Next(dir, board)[0]
-	closestX := math.Min(float64(nextHead.X), float64(board.Width-nextHead.X)) + 1
-	closestY := math.Min(float64(nextHead.Y), float64(board.Width-nextHead.Y)) + 1
-	return (closestX / float64(board.Width+1) / 2.0) * (closestY / float64(board.Height+1) / 2.0)
+	if _, wrapped := board.Topology().(wrappedTopology); wrapped {
+		return 1
+	}
+	nextHead := me.Advance(dir, board).Head
+	zone := safeZoneOf(board)
+	width, height := zone.maxX-zone.minX+1, zone.maxY-zone.minY+1
+	closestX := math.Max(0, math.Min(float64(nextHead.X-zone.minX), float64(zone.maxX+1-nextHead.X))) + 1
+	closestY := math.Max(0, math.Min(float64(nextHead.Y-zone.minY), float64(zone.maxY+1-nextHead.Y))) + 1
+	return (closestX / float64(width+1) / 2.0) * (closestY / float64(height+1) / 2.0)
 }
 
-// This function is called on every turn of a game. Use the provided GameState to decide
-// where to move -- valid moves are BattlesnakeMove_Up, BattlesnakeMove_Down, BattlesnakeMove_Left, or BattlesnakeMove_Right.
-// We've provided some code and comments to get you started.
-func move(state GameState) BattlesnakeMoveResponse {
-	start := time.Now()
-	logger := state.Logger(logging.GlobalLogger())
-
+// heuristicMoves scores every move that does not immediately leave the board or
+// run into a body, returning them back sorted from best to worst
+func heuristicMoves(logger log.Logger, state GameState) []*pMove {
 	possibleMoves := map[Direction]*pMove{}
 
 	openSpacesOnBoard := state.Board.Height * state.Board.Width
@@ -214,17 +165,23 @@ func move(state GameState) BattlesnakeMoveResponse {
 		openSpacesOnBoard -= int(snake.Length)
 	}
 
+	weights := gameModeFor(state.Board.Ruleset()).Strategy.Heuristics
+	opponents := state.Board.opponents(state.You.ID)
 	totalLenDiff := 0.0
-	for _, snake := range otherSnakes(state.You.ID, state.Board.Snakes) {
+	for _, snake := range opponents {
 		totalLenDiff += float64(snake.Length - state.You.Length)
 	}
+	// the board with the hazard that's coming soon, so we stay clear of it
+	forecast := forecastHazards(state, royaleForecastTurns)
+	food := planFood(state.You, state.Board)
+	chokes := findChokepoints(state.Board)
 	for _, dir := range state.You.Moves(logger) {
 		dirLogger := log.With(logger, "dir", dir)
-		nextBody := state.You.Next(dir, state.Board)
-		if state.Board.OutOfBounds(nextBody[0]) {
+		next := state.You.Advance(dir, state.Board)
+		if state.Board.OutOfBounds(next.Head) {
 			_ = level.Debug(dirLogger).Log("msg", "out of bounds")
 			continue
-		} else if state.Board.Occupied(nextBody[0]) {
+		} else if state.Board.Occupied(next.Head) {
 			_ = level.Debug(dirLogger).Log("msg", "occupied")
 			continue
 		}
@@ -233,25 +190,49 @@ func move(state GameState) BattlesnakeMoveResponse {
 			weight: 1.0,
 		}
 
-		foodAvailability := foodWeight(comparator[dir], state.You.Head, state.Board)
-		avgLenDiff := totalLenDiff / float64(len(otherSnakes(state.You.ID, state.Board.Snakes)))
+		foodAvailability := food.weight(dir)
+		avgLenDiff := 0.0
+		if len(opponents) > 0 {
+			avgLenDiff = totalLenDiff / float64(len(opponents))
+		}
 		healthScale := foodAvailability
 		if state.You.Health > 60 && avgLenDiff < 0 {
 			healthScale = 1 - foodAvailability
 		}
-		possibleMoves[dir].weight *= math.Pow(healthScale, 0.5*math.Sqrt(math.Max(0, avgLenDiff)))
+		possibleMoves[dir].weight *= math.Pow(healthScale, weights.Food*math.Sqrt(math.Max(0, avgLenDiff)))
+
+		// hazards are worth crossing while there's health to spare
+		healthWeight := float64(next.Health) / float64(state.You.Health-1)
+		if next.Health >= state.You.Health-1 {
+			healthWeight = 1
+		}
+		possibleMoves[dir].weight *= healthWeight
 
-		snakeWeight := otherSnakeWeight(comparator[dir], state.You, state.Board)
-		possibleMoves[dir].weight *= math.Pow(snakeWeight, 1.5)
+		snakeWeight := otherSnakeWeight(state.Board.comparator(dir), state.You, state.Board)
+		possibleMoves[dir].weight *= math.Pow(snakeWeight, weights.Snake)
 
-		collisionWeight := collisionWeight(dirLogger, dir, state.You, state.Board)
-		possibleMoves[dir].weight *= math.Pow(collisionWeight, 2)
+		collisionWeight := collisionWeight(dirLogger, dir, state.You, state.Board, state.opponents)
+		possibleMoves[dir].weight *= math.Pow(collisionWeight, weights.Collision)
 
-		edgeWeight := edgeWeight(dir, state.You, state.Board)
-		possibleMoves[dir].weight *= math.Pow(edgeWeight, math.Sqrt(float64(state.Turn))/6.0)
+		edgeWeight := edgeWeight(dir, state.You, forecast)
+		possibleMoves[dir].weight *= math.Pow(edgeWeight, weights.Edge*math.Sqrt(float64(state.Turn)))
 
-		openSpaces := numOpenSpaces(dirLogger, state.You.Next(dir, state.Board), state.Board)
-		possibleMoves[dir].weight *= math.Pow(float64(openSpaces)/float64(openSpacesOnBoard), 2)
+		space := openSpaceOf(next, forecast)
+		openSpaces := space.area
+		possibleMoves[dir].weight *= math.Pow(float64(openSpaces)/float64(openSpacesOnBoard), weights.Space)
+		possibleMoves[dir].weight *= math.Pow(float64(space.safe+1)/float64(openSpaces+1), weights.Safe)
+		if space.deadEnd(len(next.Body)) {
+			_ = level.Debug(dirLogger).Log("msg", "dead end", "open_spaces", openSpaces)
+			possibleMoves[dir].weight *= weights.DeadEnd
+		}
+
+		// moving onto a chokepoint commits us to one side of it
+		split := chokes.committedSpace(next.Head)
+		possibleMoves[dir].weight *= math.Pow(split, weights.Split)
+
+		// the cells we get to first once we have moved, with the opponents yet to move
+		territory := voronoi(withSnake(state.Board, next)).territories[state.You.ID]
+		possibleMoves[dir].weight *= math.Pow(float64(territory.cells+1)/float64(openSpacesOnBoard+1), weights.Territory)
 
 		if math.IsNaN(possibleMoves[dir].weight) {
 			possibleMoves[dir].weight = -100
@@ -260,11 +241,17 @@ func move(state GameState) BattlesnakeMoveResponse {
 			"msg", "heuristics calculated",
 			"collision_weight", collisionWeight,
 			"edge_weight", edgeWeight,
+			"escape_turns", space.escapeTurns,
 			"final_weight", possibleMoves[dir].weight,
 			"food_availability", foodAvailability,
 			"health", state.You.Health,
+			"health_weight", healthWeight,
 			"open_spaces", openSpaces,
+			"safe_spaces", space.safe,
 			"snake_weight", snakeWeight,
+			"split", split,
+			"territory", territory.cells,
+			"territory_food", territory.food,
 			"total_open_spaces", openSpacesOnBoard,
 		)
 
@@ -277,27 +264,139 @@ func move(state GameState) BattlesnakeMoveResponse {
 	sort.Slice(possibleMovesList, func(i, j int) bool {
 		return possibleMovesList[i].weight > possibleMovesList[j].weight
 	})
+	return possibleMovesList
+}
 
-	var nextMove *pMove
-	if len(possibleMovesList) > 0 {
-		nextMove = possibleMovesList[0]
-		if possibleMovesList[0].weight == 0.0 {
-			_ = level.Debug(logger).Log("msg", "Moving randomly because no viable option")
-			nextMove = possibleMovesList[rand.Intn(len(possibleMovesList))]
-		}
-	} else {
-		nextMove = &pMove{
-			dir: BattlesnakeMove_Right,
-		}
-		_ = level.Debug(logger).Log("msg", "Absolutely no possible moves")
+// pickMove chooses the best of the sorted moves, falling back to the least bad
+// move when none of them are viable, see leastBadMove
+func pickMove(logger log.Logger, rng *rand.Rand, state GameState, possibleMovesList []*pMove) *pMove {
+	if len(possibleMovesList) > 0 && possibleMovesList[0].weight > 0 {
+		return possibleMovesList[0]
+	}
+	nextMove := &pMove{
+		dir: leastBadMove(state, rng),
+	}
+	_ = level.Debug(logger).Log("msg", "no viable move, making the least bad one", "move", nextMove.dir, "moves", len(possibleMovesList))
+	return nextMove
+}
+
+// heuristicResult runs the single-ply weighted heuristics as a search result
+func heuristicResult(logger log.Logger, state GameState) searchResult {
+	rng := rand.New(rand.NewSource(seedFor(state)))
+	nextMove := pickMove(logger, rng, state, heuristicMoves(logger, state))
+	return searchResult{
+		move:     nextMove.dir,
+		score:    nextMove.weight,
+		depth:    1,
+		complete: true,
 	}
+}
 
-	err := level.Info(logger).Log("msg", "making move", "move", nextMove.dir, "weight", nextMove.weight, "took_ms", time.Since(start).Milliseconds())
+// This function is called on every turn of a game. Use the provided GameState to decide
+// where to move -- valid moves are BattlesnakeMove_Up, BattlesnakeMove_Down, BattlesnakeMove_Left, or BattlesnakeMove_Right.
+// We've provided some code and comments to get you started.
+func move(state GameState) BattlesnakeMoveResponse {
+	start := time.Now()
+	logger := state.Logger(logging.GlobalLogger())
+
+	sessions.update(sessionKeyOf(state), func(sess *session) {
+		sess.observe(state)
+		state.opponents = sess.opponents.clone()
+	})
+
+	mode := gameModeFor(state.Game.Ruleset)
+	config := mode.Strategy.configure(searchSettings)
+	// the moves that can override the searched one only depend on where we are
+	// now, so they are found before the search gets the rest of the time
+	overrides := findOverrides(logger, state)
+
+	deadline := searchDeadline(start, state, config.LatencyMargin)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	search := newDepthSearch(logger, state, config)
+	result, ok := iterativeDeepening(ctx, logger, config.MaxDepth, search)
+	if !ok {
+		_ = level.Warn(logger).Log("msg", "search did not complete a single depth, using heuristics")
+		result = heuristicResult(logger, state)
+	}
+	if result.doomed() {
+		// the search assumes the worst of every opponent, so when that loses
+		// anyway the least bad move is the one that might not
+		result.move = leastBadMove(state, rand.New(rand.NewSource(seedFor(state))))
+		_ = level.Info(logger).Log("msg", "every move loses, making the least bad one", "move", result.move)
+	}
+
+	result.move = overrides.apply(logger, state, result)
+
+	keyvals := []interface{}{
+		"msg", "making move",
+		"move", result.move,
+		"weight", result.score,
+		"depth", result.depth,
+		"proven", result.proven,
+		"mode", mode.Name,
+		"budget_ms", deadline.Sub(start).Milliseconds(),
+		"took_ms", time.Since(start).Milliseconds(),
+	}
+	err := level.Info(logger).Log(append(keyvals, result.keyvals...)...)
 	if err != nil {
 		_ = level.Error(logger).Log("msg", "erorr while logging", "err", err)
 	}
 
+	sessions.update(sessionKeyOf(state), func(sess *session) {
+		sess.record(state, result.move)
+	})
 	return BattlesnakeMoveResponse{
-		Move: nextMove.dir,
+		Move: result.move,
+	}
+}
+
+// overrides are the moves worth making over the searched one in situations the
+// search can't see far enough into
+type overrides struct {
+	chase   tailChase
+	cramped bool
+	food    pMove
+	urgent  bool
+	cut     cutoff
+	sealing bool
+}
+
+// findOverrides finds the moves that could override the searched one
+func findOverrides(logger log.Logger, state GameState) overrides {
+	var o overrides
+	o.chase, o.cramped = chaseTail(logger, state)
+	o.food, o.urgent = foodCandidate(logger, state, planFood(state.You, state.Board))
+	o.cut, o.sealing = findCutoff(state)
+	return o
+}
+
+// apply returns back the move to make given the result of the search. A result
+// the search proved is left alone. Otherwise the first override that applies is
+// made instead, keeping alive before going on the attack: when cramped into less
+// room than we take up the way out is our own tail, when running out of health
+// the way to food, and sealing an opponent into a dead end is worth more than the
+// search can see. None of them is worth a move the search found loses or risks a
+// head-on.
+func (o overrides) apply(logger log.Logger, state GameState, result searchResult) BattlesnakeMove {
+	if result.proven != "" {
+		return result.move
+	}
+	safe := func(move BattlesnakeMove) bool {
+		return move != result.move && result.survives(move) &&
+			collisionWeight(logger, moveToDirection[move], state.You, state.Board, state.opponents) >= 1
+	}
+	switch {
+	case o.cramped && o.chase.turns[result.move] < o.chase.turns[o.chase.move] && safe(o.chase.move):
+		_ = level.Info(logger).Log("msg", "chasing tail", "searched_move", result.move, "chase_move", o.chase.move, "chase_turns", o.chase.turns[o.chase.move])
+		return o.chase.move
+	case o.urgent && safe(o.food.dir):
+		_ = level.Info(logger).Log("msg", "taking the path to food", "searched_move", result.move, "food_move", o.food.dir, "food_weight", o.food.weight)
+		return o.food.dir
+	case o.sealing && safe(directionToMove[o.cut.moves[0]]):
+		_ = level.Info(logger).Log("msg", "cutting off opponent", "searched_move", result.move, "target", o.cut.target, "cutoff_moves", len(o.cut.moves), "target_area", o.cut.area)
+		return directionToMove[o.cut.moves[0]]
 	}
+	return result.move
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,17 +53,184 @@ func TestMove(t *testing.T) {
 	t.Log(m.Move)
 }
 
-// TODO: More GameState test cases!
-func TestNextBody(t *testing.T) {
-	up := Coord{0, 1}
-	myBody := []Coord{
-		{2, 2}, {1, 2},
+func TestMoveWhenDoomed(t *testing.T) {
+	// both longer snakes can reach the cells beside us, so the search finds
+	// every move loses, but only the one on the left has nowhere else to go
+	state := newTestState(7, 7, nil,
+		newTestSnake("me", 100, Coord{3, 0}, Coord{3, 1}, Coord{3, 2}),
+		newTestSnake("boxed in", 100, Coord{1, 0}, Coord{0, 0}, Coord{0, 1}, Coord{1, 1}),
+		newTestSnake("free", 100, Coord{5, 0}, Coord{6, 0}, Coord{6, 1}, Coord{6, 2}),
+	)
+	state.Game.ID = "TestMoveWhenDoomed"
+	assert.Equal(t, BattlesnakeMove_Right, move(state).Move)
+}
+
+func TestOverridesApply(t *testing.T) {
+	me := newTestSnake("me", 10, Coord{3, 3}, Coord{3, 2}, Coord{3, 1})
+	// a longer snake that can meet us head-on if we move up
+	longer := newTestSnake("longer", 90, Coord{3, 5}, Coord{3, 6}, Coord{4, 6}, Coord{5, 6})
+	chase := tailChase{
+		move:  BattlesnakeMove_Up,
+		turns: map[BattlesnakeMove]int{BattlesnakeMove_Up: 4, BattlesnakeMove_Left: 1, BattlesnakeMove_Right: 1},
+	}
+	food := pMove{dir: BattlesnakeMove_Left, weight: 0.9}
+	cut := cutoff{moves: []Direction{Direction_Right}, target: "prey", area: 3}
+	searched := searchResult{move: BattlesnakeMove_Down, scores: map[BattlesnakeMove]float64{
+		BattlesnakeMove_Up: 0.5, BattlesnakeMove_Down: 1, BattlesnakeMove_Left: 0.5, BattlesnakeMove_Right: 0.5,
+	}}
+	unscored := searched
+	unscored.scores = map[BattlesnakeMove]float64{BattlesnakeMove_Down: 1}
+	upLoses := searched
+	upLoses.scores = map[BattlesnakeMove]float64{
+		BattlesnakeMove_Up: lossScore + 2, BattlesnakeMove_Down: 1, BattlesnakeMove_Left: 0.5, BattlesnakeMove_Right: 0.5,
+	}
+	proven := searched
+	proven.proven = provenWin
+
+	tests := []struct {
+		name      string
+		snakes    []Battlesnake
+		overrides overrides
+		result    searchResult
+		want      BattlesnakeMove
+	}{
+		{
+			name:      "nothing to override",
+			overrides: overrides{},
+			result:    searched,
+			want:      BattlesnakeMove_Down,
+		},
+		{
+			name:      "a proven result is left alone",
+			overrides: overrides{chase: chase, cramped: true, food: food, urgent: true},
+			result:    proven,
+			want:      BattlesnakeMove_Down,
+		},
+		{
+			name:      "keeping the tail in reach comes before food",
+			overrides: overrides{chase: chase, cramped: true, food: food, urgent: true},
+			result:    searched,
+			want:      BattlesnakeMove_Up,
+		},
+		{
+			name:      "no tail chasing into a head-on",
+			snakes:    []Battlesnake{longer},
+			overrides: overrides{chase: chase, cramped: true, food: food, urgent: true},
+			result:    searched,
+			want:      BattlesnakeMove_Left,
+		},
+		{
+			name:      "no tail chasing into a move the search found loses",
+			overrides: overrides{chase: chase, cramped: true, food: food, urgent: true},
+			result:    upLoses,
+			want:      BattlesnakeMove_Left,
+		},
+		{
+			name:      "food comes before a cut-off",
+			overrides: overrides{food: food, urgent: true, cut: cut, sealing: true},
+			result:    searched,
+			want:      BattlesnakeMove_Left,
+		},
+		{
+			name:      "no override the search didn't score",
+			overrides: overrides{chase: chase, cramped: true, food: food, urgent: true, cut: cut, sealing: true},
+			result:    unscored,
+			want:      BattlesnakeMove_Down,
+		},
+		{
+			name:      "a cut-off",
+			overrides: overrides{cut: cut, sealing: true},
+			result:    searched,
+			want:      BattlesnakeMove_Right,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newTestState(7, 7, nil, append([]Battlesnake{me}, tt.snakes...)...)
+			assert.Equal(t, tt.want, tt.overrides.apply(log.NewNopLogger(), state, tt.result))
+		})
 	}
+}
+
+// TODO: More GameState test cases!
+func TestAdvance(t *testing.T) {
 	board := Board{
-		Height: 5,
-		Width:  5,
+		Height:  5,
+		Width:   5,
+		Food:    []Coord{{2, 3}},
+		Hazards: []Coord{{2, 1}},
+	}
+	tests := []struct {
+		name       string
+		body       []Coord
+		dir        Direction
+		wantBody   []Coord
+		wantHealth int32
+	}{
+		{
+			name:       "moves without food",
+			body:       []Coord{{2, 2}, {1, 2}},
+			dir:        Direction_Right,
+			wantBody:   []Coord{{3, 2}, {2, 2}},
+			wantHealth: 49,
+		},
+		{
+			name:       "grows on food by duplicating tail",
+			body:       []Coord{{2, 2}, {1, 2}, {0, 2}},
+			dir:        Direction_Up,
+			wantBody:   []Coord{{2, 3}, {2, 2}, {1, 2}, {1, 2}},
+			wantHealth: SnakeMaxHealth,
+		},
+		{
+			name:       "stacked tail at game start",
+			body:       []Coord{{1, 1}, {1, 1}, {1, 1}},
+			dir:        Direction_Down,
+			wantBody:   []Coord{{1, 0}, {1, 1}, {1, 1}},
+			wantHealth: 49,
+		},
+		{
+			name:       "stacked tail unstacks after two moves",
+			body:       []Coord{{1, 0}, {1, 1}, {1, 1}},
+			dir:        Direction_Right,
+			wantBody:   []Coord{{2, 0}, {1, 0}, {1, 1}},
+			wantHealth: 49,
+		},
+		{
+			name:       "takes hazard damage",
+			body:       []Coord{{2, 2}, {1, 2}},
+			dir:        Direction_Down,
+			wantBody:   []Coord{{2, 1}, {2, 2}},
+			wantHealth: 49 - DefaultHazardDamagePerTurn,
+		},
+		{
+			name:       "length one",
+			body:       []Coord{{0, 0}},
+			dir:        Direction_Right,
+			wantBody:   []Coord{{1, 0}},
+			wantHealth: 49,
+		},
+		{
+			name:       "length one eats",
+			body:       []Coord{{2, 2}},
+			dir:        Direction_Up,
+			wantBody:   []Coord{{2, 3}, {2, 3}},
+			wantHealth: SnakeMaxHealth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := make([]Coord, len(tt.body))
+			copy(body, tt.body)
+			snake := Battlesnake{Health: 50, Head: body[0], Body: body, Length: int32(len(body))}
+
+			next := snake.Advance(tt.dir, board)
+
+			assert.Equal(t, tt.wantBody, next.Body)
+			assert.Equal(t, tt.wantBody[0], next.Head)
+			assert.EqualValues(t, len(tt.wantBody), next.Length)
+			assert.Equal(t, tt.wantHealth, next.Health)
+			assert.Equal(t, tt.body, snake.Body, "original snake should not be modified")
+		})
 	}
-	nBody := nextBody(up, myBody, board)
-	assert.Len(t, nBody, 2)
-	assert.EqualValues(t, []Coord{{2, 3}, {2, 2}}, nBody)
 }
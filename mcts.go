@@ -0,0 +1,317 @@
+package main
+
+// This file contains a Monte Carlo tree search for simultaneous moves using
+// decoupled UCT: at every node each snake picks its own move with UCB1 from its
+// own statistics, and the joint move picks the child. New children are scored
+// by playing the game out with a rollout policy.
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+const (
+	// mctsExploration is the UCB1 exploration constant; rewards are within [0, 1]
+	mctsExploration = 1.0
+	// rolloutTurns is the most turns a rollout plays before scoring the state
+	rolloutTurns = 20
+)
+
+// rolloutPolicy picks the move the snake makes during a rollout
+type rolloutPolicy func(rng *rand.Rand, state GameState, snake Battlesnake) BattlesnakeMove
+
+const (
+	rolloutRandom    = "random"
+	rolloutFloodFill = "floodfill"
+	rolloutHeuristic = "heuristic"
+)
+
+var rolloutPolicies = map[string]rolloutPolicy{
+	rolloutRandom:    randomSafeRollout,
+	rolloutFloodFill: floodFillRollout,
+	rolloutHeuristic: heuristicRollout,
+}
+
+// randomSafeRollout picks any move that doesn't immediately leave the board or run
+// into a body
+func randomSafeRollout(rng *rand.Rand, state GameState, snake Battlesnake) BattlesnakeMove {
+	moves := candidateMoves(snake, state.Board)
+	return moves[rng.Intn(len(moves))]
+}
+
+// floodFillRollout picks the safe move that leaves the snake the most open space,
+// breaking ties randomly
+func floodFillRollout(rng *rand.Rand, state GameState, snake Battlesnake) BattlesnakeMove {
+	best, bestSpaces, ties := BattlesnakeMove(""), -1, 0
+	for _, m := range candidateMoves(snake, state.Board) {
+		spaces := numOpenSpaces(log.NewNopLogger(), snake.Advance(moveToDirection[m], state.Board), state.Board)
+		switch {
+		case spaces > bestSpaces:
+			best, bestSpaces, ties = m, spaces, 1
+		case spaces == bestSpaces:
+			ties++
+			if rng.Intn(ties) == 0 {
+				best = m
+			}
+		}
+	}
+	return best
+}
+
+// heuristicRollout picks the move the single-ply heuristics in move would make if
+// they were playing as the snake
+func heuristicRollout(rng *rand.Rand, state GameState, snake Battlesnake) BattlesnakeMove {
+	state.You = snake
+	logger := log.NewNopLogger()
+	return pickMove(logger, rng, state, heuristicMoves(logger, state)).dir
+}
+
+// seedFor returns back a seed that is the same every time the state is seen
+func seedFor(state GameState) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(state.Game.ID))
+	_, _ = h.Write([]byte(state.You.ID))
+	return int64(h.Sum64()) + int64(state.Turn)
+}
+
+type mctsNode struct {
+	state    GameState
+	terminal bool
+	// snakes are the IDs of the snakes still on the board, indexing the slices below
+	snakes  []string
+	moves   [][]BattlesnakeMove
+	visits  [][]int
+	rewards [][]float64
+	total   int
+	// children are keyed by the joint move that leads to them
+	children map[string]*mctsNode
+}
+
+type mctsTree struct {
+	ctx         context.Context
+	rng         *rand.Rand
+	policy      rolloutPolicy
+	rootID      string
+	ids         []string
+	multiplayer bool
+	root        *mctsNode
+}
+
+// mctsDepthSearch returns back a depthSearch that ignores depth and keeps adding
+// to the tree until the context is done. The move with the most visits is made.
+func mctsDepthSearch(logger log.Logger, state GameState, policyName string) depthSearch {
+	return func(ctx context.Context, depth int) (searchResult, error) {
+		t := newMCTSTree(state, policyName)
+		t.ctx = ctx
+		for ctx.Err() == nil {
+			t.simulate(t.root, 0)
+		}
+		move, visits := t.best()
+		return searchResult{
+			move:     move,
+			score:    t.meanReward(move),
+			depth:    depth,
+			complete: true,
+			keyvals:  []interface{}{"search", "mcts", "rollout", policyName, "iterations", t.root.total, "visits", visits},
+		}, nil
+	}
+}
+
+func newMCTSTree(state GameState, policyName string) *mctsTree {
+	policy, ok := rolloutPolicies[policyName]
+	if !ok {
+		policy = floodFillRollout
+	}
+	ids := make([]string, len(state.Board.Snakes))
+	for i, snake := range state.Board.Snakes {
+		ids[i] = snake.ID
+	}
+	t := &mctsTree{
+		ctx:         context.Background(),
+		rng:         rand.New(rand.NewSource(seedFor(state))),
+		policy:      policy,
+		rootID:      state.You.ID,
+		ids:         ids,
+		multiplayer: len(state.Board.opponents(state.You.ID)) > 0,
+	}
+	t.root = t.newNode(state)
+	return t
+}
+
+func (t *mctsTree) newNode(state GameState) *mctsNode {
+	node := &mctsNode{
+		state:    state,
+		terminal: t.over(state),
+		children: map[string]*mctsNode{},
+	}
+	if node.terminal {
+		return node
+	}
+	for _, snake := range state.Board.Snakes {
+		moves := candidateMoves(snake, state.Board)
+		node.snakes = append(node.snakes, snake.ID)
+		node.moves = append(node.moves, moves)
+		node.visits = append(node.visits, make([]int, len(moves)))
+		node.rewards = append(node.rewards, make([]float64, len(moves)))
+	}
+	return node
+}
+
+// over returns back whether the game is over as far as our snake is concerned
+func (t *mctsTree) over(state GameState) bool {
+	return !state.Alive(t.rootID) || (t.multiplayer && len(state.Board.opponents(t.rootID)) == 0)
+}
+
+// simulate runs a single iteration from the node: selecting down the tree,
+// expanding a new child, rolling out from it and updating the statistics on the
+// way back up. It returns back the reward of every snake.
+func (t *mctsTree) simulate(node *mctsNode, ply int) map[string]float64 {
+	if node.terminal {
+		return t.rewardsFor(node.state, ply, nil)
+	}
+
+	choice := make([]int, len(node.snakes))
+	moves := make(map[string]BattlesnakeMove, len(node.snakes))
+	key := make([]string, len(node.snakes))
+	for i, id := range node.snakes {
+		choice[i] = node.selectMove(i)
+		moves[id] = node.moves[i][choice[i]]
+		key[i] = string(moves[id])
+	}
+
+	var rewards map[string]float64
+	joint := strings.Join(key, ",")
+	child, ok := node.children[joint]
+	if ok {
+		rewards = t.simulate(child, ply+1)
+	} else {
+		child = t.newNode(Step(node.state, moves))
+		node.children[joint] = child
+		rewards = t.rollout(child.state, ply+1)
+	}
+
+	node.total++
+	for i, id := range node.snakes {
+		node.visits[i][choice[i]]++
+		node.rewards[i][choice[i]] += rewards[id]
+	}
+	return rewards
+}
+
+// selectMove returns back the index of the move the snake picks with UCB1, trying
+// every move once first
+func (node *mctsNode) selectMove(i int) int {
+	best, bestValue := 0, math.Inf(-1)
+	for j, visits := range node.visits[i] {
+		if visits == 0 {
+			return j
+		}
+		value := node.rewards[i][j]/float64(visits) +
+			mctsExploration*math.Sqrt(math.Log(float64(node.total))/float64(visits))
+		if value > bestValue {
+			best, bestValue = j, value
+		}
+	}
+	return best
+}
+
+// rollout plays the game out from the state with the rollout policy and returns
+// back the reward of every snake. The rollout is cut short once the context is
+// done.
+func (t *mctsTree) rollout(state GameState, ply int) map[string]float64 {
+	diedAt := map[string]int{}
+	for turn := 0; turn < rolloutTurns && !t.over(state) && t.ctx.Err() == nil; turn++ {
+		moves := make(map[string]BattlesnakeMove, len(state.Board.Snakes))
+		for _, snake := range state.Board.Snakes {
+			moves[snake.ID] = t.policy(t.rng, state, snake)
+		}
+		next := Step(state, moves)
+		for _, snake := range state.Board.Snakes {
+			if !next.Alive(snake.ID) {
+				diedAt[snake.ID] = ply + turn + 1
+			}
+		}
+		state = next
+	}
+	return t.rewardsFor(state, ply, diedAt)
+}
+
+// rewardsFor scores the state for every snake between 0 and 1. Eliminated snakes
+// score at most 0.25, more the later they died; the last snake standing scores 1
+// and any other snake scores between 0.25 and 0.75 by how long it is.
+func (t *mctsTree) rewardsFor(state GameState, ply int, diedAt map[string]int) map[string]float64 {
+	horizon := float64(ply + rolloutTurns)
+	rewards := make(map[string]float64, len(t.ids))
+	for _, id := range t.ids {
+		snake, alive := findSnake(id, state.Board.Snakes)
+		switch {
+		case !alive:
+			died, ok := diedAt[id]
+			if !ok {
+				died = ply
+			}
+			rewards[id] = 0.25 * float64(died) / horizon
+		case t.multiplayer && len(state.Board.opponents(id)) == 0:
+			rewards[id] = 1
+		default:
+			lengthLead := 0.0
+			others := state.Board.opponents(id)
+			for _, other := range others {
+				lengthLead += float64(snake.Length - other.Length)
+			}
+			if len(others) > 0 {
+				lengthLead /= float64(len(others))
+			}
+			rewards[id] = 0.5 + 0.25*math.Tanh(lengthLead/4)
+		}
+	}
+	return rewards
+}
+
+// best returns back our most visited move at the root along with the visit count
+// of every move
+func (t *mctsTree) best() (BattlesnakeMove, map[BattlesnakeMove]int) {
+	visits := map[BattlesnakeMove]int{}
+	i := t.rootIndex()
+	if i < 0 {
+		return candidateMoves(t.root.state.You, t.root.state.Board)[0], visits
+	}
+	best := t.root.moves[i][0]
+	for j, m := range t.root.moves[i] {
+		visits[m] = t.root.visits[i][j]
+		if visits[m] > visits[best] {
+			best = m
+		}
+	}
+	return best, visits
+}
+
+// meanReward returns back our average reward from making the move at the root
+func (t *mctsTree) meanReward(move BattlesnakeMove) float64 {
+	i := t.rootIndex()
+	if i < 0 {
+		return 0
+	}
+	for j, m := range t.root.moves[i] {
+		if m == move && t.root.visits[i][j] > 0 {
+			return t.root.rewards[i][j] / float64(t.root.visits[i][j])
+		}
+	}
+	return 0
+}
+
+// rootIndex returns back the index of our snake in the root's statistics, or -1
+// if the game is already over
+func (t *mctsTree) rootIndex() int {
+	for i, id := range t.root.snakes {
+		if id == t.rootID {
+			return i
+		}
+	}
+	return -1
+}
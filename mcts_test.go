@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCTS(t *testing.T) {
+	state := newTestState(7, 7, nil,
+		newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}),
+		newTestSnake("them", 90, Coord{4, 3}, Coord{5, 3}, Coord{6, 3}, Coord{6, 2}),
+	)
+
+	for name := range rolloutPolicies {
+		t.Run(name, func(t *testing.T) {
+			tree := newMCTSTree(state, name)
+			for i := 0; i < 300; i++ {
+				tree.simulate(tree.root, 0)
+			}
+			move, visits := tree.best()
+
+			assert.NotEqual(t, BattlesnakeMove_Right, move, "should avoid head to head with a longer snake")
+			assert.Equal(t, 300, tree.root.total)
+			total := 0
+			for _, v := range visits {
+				total += v
+			}
+			assert.Equal(t, 300, total)
+		})
+	}
+}
+
+func TestMCTSIsDeterministic(t *testing.T) {
+	state := newTestState(7, 7, []Coord{{3, 5}},
+		newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}),
+		newTestSnake("them", 90, Coord{4, 1}, Coord{5, 1}, Coord{6, 1}),
+	)
+	run := func() map[BattlesnakeMove]int {
+		tree := newMCTSTree(state, rolloutRandom)
+		for i := 0; i < 200; i++ {
+			tree.simulate(tree.root, 0)
+		}
+		_, visits := tree.best()
+		return visits
+	}
+	assert.Equal(t, run(), run())
+}
+
+func TestMCTSDepthSearchRespectsDeadline(t *testing.T) {
+	state := newTestState(11, 11, nil,
+		newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}),
+		newTestSnake("them", 90, Coord{8, 8}, Coord{8, 7}, Coord{8, 6}),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := mctsDepthSearch(log.NewNopLogger(), state, rolloutRandom)(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.True(t, result.complete)
+	assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	assert.Contains(t, result.keyvals, "visits")
+}
+
+func TestRandomSafeRollout(t *testing.T) {
+	// boxed into the corner so the only safe move is up
+	state := newTestState(5, 5, nil,
+		newTestSnake("me", 90, Coord{0, 0}, Coord{1, 0}, Coord{2, 0}),
+		newTestSnake("them", 90, Coord{1, 1}, Coord{2, 1}, Coord{3, 1}, Coord{3, 2}),
+	)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, BattlesnakeMove_Up, randomSafeRollout(rng, state, state.You))
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+// This file contains the multiplayer tree search. Every snake on the board picks
+// a move each turn and the state is advanced with Step once all of them have.
+//
+// Simultaneous moves are searched as if the snakes picked in turn, our snake
+// first, but the board only changes once everybody has picked. How the
+// opponents pick depends on the mode:
+//
+//	paranoid: every opponent picks the move that is worst for us
+//	max-n: every snake picks the move that is best for itself
+//
+// Searched states are kept in a transposition table across iterations. Scores of
+// states where the game is over depend on how many turns it took to get there,
+// so a transposition reached in a different number of turns may be off by a few
+// points, which only changes which of two wins or losses is preferred.
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+)
+
+// scores are the values of a state for each snake, keyed by snake ID
+type scores map[string]float64
+
+type multiplayerSearch struct {
+	ctx     context.Context
+	logger  log.Logger
+	mode    searchAlgorithm
+	weights evalWeights
+	rootID  string
+	// ids are the snakes on the board when the search started
+	ids []string
+	// squad are our squadmates, who play along with us in paranoid mode
+	squad map[string]bool
+	// multiplayer is set when there were opponents when the search started, so
+	// being the last snake standing is a win
+	multiplayer bool
+	tt          *transpositionTable
+	// rootScores are our scores for each of our moves at the root
+	rootScores map[BattlesnakeMove]float64
+
+	nodes int
+	// cutoff is set when any state was evaluated because the depth ran out rather
+	// than because the game was over for us
+	cutoff bool
+}
+
+// multiplayerDepthSearch returns back a depthSearch where depth is the number of
+// turns searched
+func multiplayerDepthSearch(logger log.Logger, state GameState, mode searchAlgorithm, weights evalWeights) depthSearch {
+	ids := make([]string, len(state.Board.Snakes))
+	for i, snake := range state.Board.Snakes {
+		ids[i] = snake.ID
+	}
+	squad := map[string]bool{}
+	for _, snake := range state.Board.team(state.You.ID) {
+		squad[snake.ID] = true
+	}
+	multiplayer := len(state.Board.opponents(state.You.ID)) > 0
+	tt := newTranspositionTable(ttSize)
+	return func(ctx context.Context, depth int) (searchResult, error) {
+		tt.nextGeneration()
+		s := &multiplayerSearch{
+			ctx:         ctx,
+			logger:      logger,
+			mode:        mode,
+			weights:     weights,
+			rootID:      state.You.ID,
+			ids:         ids,
+			squad:       squad,
+			multiplayer: multiplayer,
+			tt:          tt,
+			rootScores:  map[BattlesnakeMove]float64{},
+		}
+		values, move, err := s.turn(state, depth, 0)
+		if err != nil {
+			return searchResult{}, err
+		}
+		if move == "" {
+			move = candidateMoves(state.You, state.Board)[0]
+		}
+		return searchResult{
+			move:     move,
+			score:    values[s.rootID],
+			depth:    depth,
+			complete: !s.cutoff,
+			scores:   s.rootScores,
+			keyvals:  []interface{}{"search", mode, "nodes", s.nodes, "tt_hits", tt.hits},
+		}, nil
+	}
+}
+
+// turn returns back the scores of the state searched to the given number of turns
+// along with the move our snake should make
+func (s *multiplayerSearch) turn(state GameState, depth, ply int) (scores, BattlesnakeMove, error) {
+	s.nodes++
+	if s.ctx.Err() != nil {
+		return nil, "", s.ctx.Err()
+	}
+	if _, over := s.outcome(state, s.rootID, ply); over {
+		return s.leaf(state, ply), "", nil
+	}
+
+	hash := zobristHash(state)
+	if entry, ok := s.tt.lookup(hash); ok && entry.depth >= depth {
+		s.cutoff = s.cutoff || entry.cutoff
+		return entry.scores, entry.move, nil
+	}
+	if depth == 0 {
+		s.cutoff = true
+		values := s.leaf(state, ply)
+		s.tt.store(ttEntry{hash: hash, scores: values, cutoff: true})
+		return values, "", nil
+	}
+
+	order := make([]Battlesnake, 0, len(state.Board.Snakes))
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == s.rootID {
+			order = append([]Battlesnake{snake}, order...)
+		} else {
+			order = append(order, snake)
+		}
+	}
+
+	// track whether the depth ran out below this state separately from the rest
+	// of the search so it can be stored with the entry
+	cutoffAbove := s.cutoff
+	s.cutoff = false
+	values, move, err := s.choose(state, order, map[string]BattlesnakeMove{}, depth, ply)
+	if err != nil {
+		return nil, "", err
+	}
+	s.tt.store(ttEntry{hash: hash, depth: depth, scores: values, move: move, cutoff: s.cutoff})
+	s.cutoff = s.cutoff || cutoffAbove
+	return values, move, nil
+}
+
+// choose has the first snake in order pick its move given the moves already picked
+// by the snakes before it. Once every snake has picked the state is advanced.
+func (s *multiplayerSearch) choose(state GameState, order []Battlesnake, moves map[string]BattlesnakeMove, depth, ply int) (scores, BattlesnakeMove, error) {
+	if len(order) == 0 {
+		values, _, err := s.turn(Step(state, moves), depth-1, ply+1)
+		return values, "", err
+	}
+
+	snake := order[0]
+	var best scores
+	var bestMove BattlesnakeMove
+	for _, m := range candidateMoves(snake, state.Board) {
+		moves[snake.ID] = m
+		values, _, err := s.choose(state, order[1:], moves, depth, ply)
+		if err != nil {
+			return nil, "", err
+		}
+		if ply == 0 && snake.ID == s.rootID {
+			s.rootScores[m] = values[s.rootID]
+		}
+		if best == nil || s.prefers(snake.ID, values, best) {
+			best, bestMove = values, m
+		}
+	}
+	delete(moves, snake.ID)
+	return best, bestMove, nil
+}
+
+// prefers returns back whether the snake would rather have a than b
+func (s *multiplayerSearch) prefers(id string, a, b scores) bool {
+	if s.mode == algorithmParanoid && s.squad[id] {
+		return a[s.rootID] > b[s.rootID]
+	}
+	if s.mode == algorithmParanoid {
+		return a[s.rootID] < b[s.rootID]
+	}
+	return a[id] > b[id]
+}
+
+// leaf returns back the scores of the state without searching any further. In
+// paranoid mode only our own score matters.
+func (s *multiplayerSearch) leaf(state GameState, ply int) scores {
+	ids := s.ids
+	if s.mode == algorithmParanoid {
+		ids = []string{s.rootID}
+	}
+	values := make(scores, len(ids))
+	for _, id := range ids {
+		if value, over := s.outcome(state, id, ply); over {
+			values[id] = value
+		} else {
+			values[id] = s.weights.evaluate(s.logger, state, id)
+		}
+	}
+	return values
+}
+
+// outcome returns back the score of the snake if the game is over for it. Losing
+// later and winning sooner are preferred.
+func (s *multiplayerSearch) outcome(state GameState, id string, ply int) (float64, bool) {
+	alive := state.Alive(id)
+	switch {
+	case !alive && s.multiplayer && len(state.Board.Snakes) == 0:
+		return drawScore + float64(ply), true
+	case !alive:
+		return lossScore + float64(ply), true
+	case s.multiplayer && len(state.Board.opponents(id)) == 0:
+		return winScore - float64(ply), true
+	}
+	return 0, false
+}
+
+// candidateMoves returns back the moves the snake could make without immediately
+// leaving the board or running into a body. If there are none, the snake is
+// doomed and a single move is returned to keep the search from branching.
+func candidateMoves(snake Battlesnake, board Board) []BattlesnakeMove {
+	moves := make([]BattlesnakeMove, 0, len(directions))
+	fallback := BattlesnakeMove("")
+	for _, dir := range directions {
+		next := board.Topology().Move(snake.Body[0], dir)
+		if len(snake.Body) > 1 && next == snake.Body[1] {
+			continue
+		}
+		if fallback == "" {
+			fallback = directionToMove[dir]
+		}
+		if board.OutOfBounds(next) || board.Occupied(next) {
+			continue
+		}
+		moves = append(moves, directionToMove[dir])
+	}
+	if len(moves) == 0 {
+		moves = append(moves, fallback)
+	}
+	return moves
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSnake(id string, health int32, body ...Coord) Battlesnake {
+	return Battlesnake{
+		ID:     id,
+		Health: health,
+		Body:   body,
+		Head:   body[0],
+		Length: int32(len(body)),
+	}
+}
+
+func newTestState(width, height int, food []Coord, snakes ...Battlesnake) GameState {
+	return GameState{
+		Board: Board{
+			Width:  width,
+			Height: height,
+			Food:   food,
+			Snakes: snakes,
+		},
+		You: snakes[0],
+	}
+}
+
+func TestMultiplayerSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    GameState
+		depth    int
+		notMoves []BattlesnakeMove
+	}{
+		{
+			name: "avoids head to head with a longer snake",
+			state: newTestState(7, 7, nil,
+				newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}),
+				newTestSnake("them", 90, Coord{4, 3}, Coord{5, 3}, Coord{6, 3}, Coord{6, 2}),
+			),
+			depth:    1,
+			notMoves: []BattlesnakeMove{BattlesnakeMove_Right},
+		},
+		{
+			name: "does not run from a shorter snake",
+			state: newTestState(7, 7, nil,
+				newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}, Coord{0, 2}),
+				newTestSnake("them", 90, Coord{3, 4}, Coord{3, 5}, Coord{3, 6}),
+			),
+			depth:    1,
+			notMoves: []BattlesnakeMove{BattlesnakeMove_Down},
+		},
+		{
+			name: "does not run from a shorter snake two turns ahead",
+			state: newTestState(7, 7, nil,
+				newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}, Coord{0, 2}),
+				newTestSnake("them", 90, Coord{3, 4}, Coord{3, 5}, Coord{3, 6}),
+			),
+			depth:    2,
+			notMoves: []BattlesnakeMove{BattlesnakeMove_Down},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []searchAlgorithm{algorithmParanoid, algorithmMaxN} {
+			t.Run(tt.name+"/"+string(mode), func(t *testing.T) {
+				search := multiplayerDepthSearch(log.NewNopLogger(), tt.state, mode, defaultEvalWeights)
+				result, err := search(context.Background(), tt.depth)
+				assert.NoError(t, err)
+				assert.NotContains(t, tt.notMoves, result.move)
+				assert.Equal(t, result.score, result.scores[result.move])
+				assert.True(t, result.survives(result.move))
+			})
+		}
+	}
+}
+
+func TestMultiplayerSearchCompletesWhenDoomed(t *testing.T) {
+	state := newTestState(3, 1, nil,
+		newTestSnake("me", 90, Coord{0, 0}, Coord{1, 0}, Coord{2, 0}),
+	)
+	search := multiplayerDepthSearch(log.NewNopLogger(), state, algorithmParanoid, defaultEvalWeights)
+	result, err := search(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.True(t, result.complete)
+	assert.Equal(t, lossScore+1, result.score)
+	assert.False(t, result.survives(result.move))
+}
+
+func TestMultiplayerSearchScoresRootMoves(t *testing.T) {
+	state := newTestState(7, 7, nil,
+		newTestSnake("me", 90, Coord{2, 3}, Coord{1, 3}, Coord{0, 3}),
+		newTestSnake("them", 90, Coord{4, 3}, Coord{5, 3}, Coord{6, 3}, Coord{6, 2}),
+	)
+	search := multiplayerDepthSearch(log.NewNopLogger(), state, algorithmParanoid, defaultEvalWeights)
+	result, err := search(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, result.scores, 3)
+	// the longer snake can meet us head-on to the right
+	assert.False(t, result.survives(BattlesnakeMove_Right))
+	assert.True(t, result.survives(BattlesnakeMove_Up))
+	assert.True(t, result.survives(BattlesnakeMove_Down))
+}
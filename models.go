@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"math"
 
 	"github.com/go-kit/log"
@@ -12,6 +13,11 @@ type GameState struct {
 	Turn  int         `json:"turn"`
 	Board Board       `json:"board"`
 	You   Battlesnake `json:"you"`
+
+	// opponents are the models of how the opponents have moved so far this game
+	opponents opponentModels
+	// food is the food field of the state a search started from, see foodField
+	food *foodField
 }
 
 func (state GameState) Logger(logger log.Logger) log.Logger {
@@ -24,9 +30,40 @@ type Game struct {
 	Timeout int32   `json:"timeout"`
 }
 
+// UnmarshalJSON decodes the state and copies the game's ruleset onto the board
+// so that everything working off the board follows the rules of the game
+func (state *GameState) UnmarshalJSON(data []byte) error {
+	type plain GameState
+	if err := json.Unmarshal(data, (*plain)(state)); err != nil {
+		return err
+	}
+	state.Board.ruleset = state.Game.Ruleset
+	return nil
+}
+
 type Ruleset struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name     string          `json:"name"`
+	Version  string          `json:"version"`
+	Settings RulesetSettings `json:"settings"`
+}
+
+type RulesetSettings struct {
+	FoodSpawnChance int32 `json:"foodSpawnChance"`
+	MinimumFood     int32 `json:"minimumFood"`
+	// HazardDamagePerTurn is nil when the setting wasn't sent
+	HazardDamagePerTurn *int32 `json:"hazardDamagePerTurn"`
+
+	Royale RoyaleSettings `json:"royale"`
+	Squad  SquadSettings  `json:"squad"`
+}
+
+// HazardDamagePerTurn returns back the health a snake loses for every hazard
+// its head is on, falling back to the official default when it wasn't sent
+func (r Ruleset) HazardDamagePerTurn() int32 {
+	if r.Settings.HazardDamagePerTurn != nil {
+		return *r.Settings.HazardDamagePerTurn
+	}
+	return DefaultHazardDamagePerTurn
 }
 
 type Board struct {
@@ -37,23 +74,41 @@ type Board struct {
 
 	// Used in non-standard game modes
 	Hazards []Coord `json:"hazards"`
+
+	// ruleset is the ruleset of the game the board is from
+	ruleset Ruleset
+}
+
+// Ruleset returns back the ruleset of the game the board is from
+func (b Board) Ruleset() Ruleset {
+	return b.ruleset
+}
+
+// hazardDamage returns back the health a snake loses for moving its head onto the coordinate
+func (b Board) hazardDamage(c Coord) int32 {
+	damage := int32(0)
+	for _, h := range b.Hazards {
+		if h == c {
+			damage += b.ruleset.HazardDamagePerTurn()
+		}
+	}
+	return damage
 }
 
 func (b Board) OutOfBounds(c Coord) bool {
-	return c.X >= b.Width ||
-		c.X < 0 ||
-		c.Y >= b.Height ||
-		c.Y < 0
+	return b.Topology().OutOfBounds(c)
 }
 
 // Occupied returns back true if regardless of any movement if the coordinate will be
-// occupied by a hazard or a snake body
+// occupied by a snake body. Hazards only cost health so they don't occupy anything.
+// Tails move out of the way unless snakes grow every turn.
 func (b Board) Occupied(c Coord) bool {
-	if CoordSliceContains(c, b.Hazards) {
-		return true
-	}
 	for _, snake := range b.Snakes {
-		if CoordSliceContains(c, snake.Body[:snake.Length-1]) {
+		blocking := snake.Body[:len(snake.Body)-1]
+		if b.ruleset.growsEveryTurn() {
+			blocking = snake.Body
+		}
+		if CoordSliceContains(c, blocking) {
 			return true
 		}
 	}
@@ -93,22 +148,38 @@ type Battlesnake struct {
 	Squad string `json:"squad"`
 }
 
-// Next returns back a new slice of coordinates the represents the new snake body
-// If addOne is `true` then the body has an additional segment
-func (snake Battlesnake) Next(dir Direction, board Board) []Coord {
-	nextBody := make([]Coord, 1)
-	nextBody[0] = Coord(dir).Add(snake.Body[0])
-	nextBody = append(nextBody, snake.Body...)
-	if CoordSliceContains(nextBody[0], board.Food) {
-		nextBody = nextBody[:len(nextBody)-1]
+// Advance returns back the snake after moving in the given direction: the head
+// moves, the tail drops and health is reduced by one. If the new head is on food
+// the snake eats it, restoring its health and growing by duplicating its tail
+// segment, which is what the official engine does. Otherwise a head on a hazard
+// takes the hazard's damage, down to no health at all. In rulesets where snakes
+// grow every turn they always eat.
+func (snake Battlesnake) Advance(dir Direction, board Board) Battlesnake {
+	body := make([]Coord, len(snake.Body), len(snake.Body)+1)
+	body[0] = board.Topology().Move(snake.Body[0], dir)
+	copy(body[1:], snake.Body[:len(snake.Body)-1])
+	snake.Health--
+
+	if board.ruleset.growsEveryTurn() || CoordSliceContains(body[0], board.Food) {
+		body = append(body, body[len(body)-1])
+		snake.Health = SnakeMaxHealth
+	} else if damage := board.hazardDamage(body[0]); damage > 0 {
+		snake.Health -= damage
+		if snake.Health < 0 {
+			snake.Health = 0
+		}
 	}
-	return nextBody
+
+	snake.Body = body
+	snake.Head = body[0]
+	snake.Length = int32(len(body))
+	return snake
 }
 
 func (snake Battlesnake) Moves(logger log.Logger) []Direction {
 	moves := []Direction{}
 	snakeDirection := snake.Direction()
-	for _, dir := range moveToDirection {
+	for _, dir := range directions {
 		if Coord(dir) != Coord(snakeDirection).Reverse() {
 			moves = append(moves, dir)
 		}
@@ -118,11 +189,10 @@ func (snake Battlesnake) Moves(logger log.Logger) []Direction {
 }
 
 func (snake Battlesnake) Direction() Direction {
-	if snake.Length < 2 {
+	if len(snake.Body) < 2 {
 		return Direction_Right
 	}
-	head, neck := snake.Head, snake.Body[1]
-	return Direction(head.Add(neck.Reverse()))
+	return stepDirection(snake.Body[1], snake.Head)
 }
 
 type Direction Coord
@@ -134,6 +204,10 @@ var (
 	Direction_Right = Direction{1, 0}
 )
 
+// directions lists every direction in a fixed order so that iterating over them
+// is deterministic
+var directions = []Direction{Direction_Up, Direction_Down, Direction_Left, Direction_Right}
+
 var moveToDirection = map[BattlesnakeMove]Direction{
 	BattlesnakeMove_Down:  Direction_Down,
 	BattlesnakeMove_Up:    Direction_Up,
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameStateUnmarshalJSON(t *testing.T) {
+	data := `{
+		"game": {
+			"id": "game",
+			"ruleset": {
+				"name": "royale",
+				"version": "v1.0.0",
+				"settings": {"foodSpawnChance": 15, "minimumFood": 1, "hazardDamagePerTurn": 20, "royale": {"shrinkEveryNTurns": 25}, "squad": {"allowBodyCollisions": true, "sharedElimination": true, "sharedHealth": false, "sharedLength": true}}
+			},
+			"timeout": 500
+		},
+		"turn": 3,
+		"board": {"height": 11, "width": 11, "food": [], "hazards": [{"x": 0, "y": 0}], "snakes": []},
+		"you": {"id": "me"}
+	}`
+
+	state := GameState{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &state))
+	assert.Equal(t, "royale", state.Game.Ruleset.Name)
+	assert.Equal(t, RulesetSettings{
+		FoodSpawnChance:     15,
+		MinimumFood:         1,
+		HazardDamagePerTurn: damagePerTurn(20),
+		Royale:              RoyaleSettings{ShrinkEveryNTurns: 25},
+		Squad:               SquadSettings{AllowBodyCollisions: true, SharedElimination: true, SharedLength: true},
+	}, state.Game.Ruleset.Settings)
+	assert.Equal(t, state.Game.Ruleset, state.Board.Ruleset())
+	assert.Equal(t, 3, state.Turn)
+	assert.EqualValues(t, 20, state.Board.hazardDamage(Coord{0, 0}))
+	assert.EqualValues(t, 0, state.Board.hazardDamage(Coord{1, 0}))
+}
+
+// damagePerTurn returns back the setting for hazards dealing the damage
+func damagePerTurn(damage int32) *int32 {
+	return &damage
+}
+
+func TestHazardDamagePerTurnDefault(t *testing.T) {
+	assert.Equal(t, DefaultHazardDamagePerTurn, Ruleset{}.HazardDamagePerTurn())
+
+	state := GameState{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"game": {"ruleset": {"settings": {"hazardDamagePerTurn": 0}}}}`), &state))
+	assert.EqualValues(t, 0, state.Game.Ruleset.HazardDamagePerTurn())
+	state = GameState{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"game": {"ruleset": {"settings": {}}}}`), &state))
+	assert.Equal(t, DefaultHazardDamagePerTurn, state.Game.Ruleset.HazardDamagePerTurn())
+}
+
+func TestOccupiedIgnoresHazards(t *testing.T) {
+	board := Board{Height: 3, Width: 3, Hazards: []Coord{{1, 1}}}
+	assert.False(t, board.Occupied(Coord{1, 1}))
+}
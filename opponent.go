@@ -0,0 +1,159 @@
+package main
+
+// This file contains the opponent model. Every turn the move each opponent made
+// is worked out from how its head moved since the turn before, and the model
+// keeps count of what kind of move it was: towards food, towards our head, along
+// a wall or straight ahead. The counts give the odds of the opponent making each
+// of the moves open to it next, which collisionWeight uses to judge how likely a
+// head-on collision is.
+//
+// An opponent that hasn't been seen yet is equally likely to make any move that
+// doesn't immediately leave the board or run into a body.
+
+// moveFeature is a kind of move an opponent can make
+type moveFeature int
+
+const (
+	// featureFood moves closer to the nearest food
+	featureFood moveFeature = iota
+	// featureHead moves closer to our head
+	featureHead
+	// featureWall ends up next to a wall
+	featureWall
+	// featureStraight keeps going the same way
+	featureStraight
+	numMoveFeatures
+)
+
+type moveFeatures [numMoveFeatures]bool
+
+// featuresOf returns back the kinds of move the snake makes by moving in the
+// direction, target being our head
+func featuresOf(snake Battlesnake, dir Direction, board Board, target Coord) moveFeatures {
+	next := board.Topology().Move(snake.Head, dir)
+	var features moveFeatures
+	nearest := func(c Coord) int {
+		closest := never
+		for _, f := range board.Food {
+			if d := board.Manhattan(c, f); d < closest {
+				closest = d
+			}
+		}
+		return closest
+	}
+	features[featureFood] = len(board.Food) > 0 && nearest(next) < nearest(snake.Head)
+	features[featureHead] = board.Manhattan(next, target) < board.Manhattan(snake.Head, target)
+	if _, wrapped := board.Topology().(wrappedTopology); !wrapped {
+		features[featureWall] = next.X == 0 || next.Y == 0 || next.X == board.Width-1 || next.Y == board.Height-1
+	}
+	features[featureStraight] = len(snake.Body) > 1 && snake.Body[1] != snake.Head && dir == snake.Direction()
+	return features
+}
+
+// opponentModel counts the kinds of move an opponent made
+type opponentModel struct {
+	// offered counts the turns the snake could choose between moves of each kind
+	// and moves of other kinds, and taken the turns it then chose that kind
+	offered [numMoveFeatures]int
+	taken   [numMoveFeatures]int
+	// observed is the number of moves seen
+	observed int
+}
+
+// observe counts the move the snake made in the direction on the board, target
+// being our head. Moves that weren't safe to make tell nothing about the snake
+// and aren't counted.
+func (m *opponentModel) observe(snake Battlesnake, board Board, target Coord, dir Direction) {
+	if next := board.Topology().Move(snake.Head, dir); board.OutOfBounds(next) || board.Occupied(next) {
+		return
+	}
+	options := candidateMoves(snake, board)
+
+	var some, all moveFeatures
+	for i := range all {
+		all[i] = true
+	}
+	for _, option := range options {
+		features := featuresOf(snake, moveToDirection[option], board, target)
+		for f, has := range features {
+			some[f] = some[f] || has
+			all[f] = all[f] && has
+		}
+	}
+	chosen := featuresOf(snake, dir, board, target)
+	for f := range chosen {
+		if some[f] && !all[f] {
+			m.offered[f]++
+			if chosen[f] {
+				m.taken[f]++
+			}
+		}
+	}
+	m.observed++
+}
+
+// rate returns back how often the snake chooses the kind of move when it can,
+// starting out at even odds
+func (m *opponentModel) rate(f moveFeature) float64 {
+	if m == nil {
+		return 0.5
+	}
+	return float64(m.taken[f]+1) / float64(m.offered[f]+2)
+}
+
+// predict returns back the probability of the snake making each move that
+// doesn't immediately leave the board or run into a body, target being our head
+func (m *opponentModel) predict(snake Battlesnake, board Board, target Coord) map[Direction]float64 {
+	options := candidateMoves(snake, board)
+	odds := make(map[Direction]float64, len(options))
+	total := 0.0
+	for _, option := range options {
+		dir := moveToDirection[option]
+		p := 1.0
+		for f, has := range featuresOf(snake, dir, board, target) {
+			if has {
+				p *= m.rate(moveFeature(f))
+			} else {
+				p *= 1 - m.rate(moveFeature(f))
+			}
+		}
+		odds[dir] = p
+		total += p
+	}
+	for dir := range odds {
+		odds[dir] /= total
+	}
+	return odds
+}
+
+// opponentModels are the models of the opponents in a game, by snake ID
+type opponentModels map[string]*opponentModel
+
+// observe counts the moves every opponent made between the states, which have
+// to be of consecutive turns
+func (ms opponentModels) observe(prev, state GameState) {
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			continue
+		}
+		before, ok := findSnake(snake.ID, prev.Board.Snakes)
+		if !ok || prev.Board.Manhattan(before.Head, snake.Head) != 1 {
+			continue
+		}
+		if ms[snake.ID] == nil {
+			ms[snake.ID] = &opponentModel{}
+		}
+		ms[snake.ID].observe(before, prev.Board, prev.You.Head, stepDirection(before.Head, snake.Head))
+	}
+}
+
+// clone returns back a copy of the models that can be read while these are
+// being updated
+func (ms opponentModels) clone() opponentModels {
+	c := make(opponentModels, len(ms))
+	for id, m := range ms {
+		copied := *m
+		c[id] = &copied
+	}
+	return c
+}
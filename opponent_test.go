@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeaturesOf(t *testing.T) {
+	board := Board{Width: 7, Height: 7, Food: []Coord{{3, 6}}}
+	snake := newTestSnake("them", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1})
+	target := Coord{6, 3}
+
+	tests := []struct {
+		dir  Direction
+		want moveFeatures
+	}{
+		{Direction_Up, moveFeatures{featureFood: true, featureStraight: true}},
+		{Direction_Left, moveFeatures{}},
+		{Direction_Right, moveFeatures{featureHead: true}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, featuresOf(snake, tt.dir, board, target), "%v", tt.dir)
+	}
+
+	along := newTestSnake("them", 90, Coord{1, 3}, Coord{2, 3}, Coord{3, 3})
+	assert.True(t, featuresOf(along, Direction_Left, board, target)[featureWall])
+	board.ruleset = Ruleset{Name: rulesetWrapped}
+	assert.False(t, featuresOf(along, Direction_Left, board, target)[featureWall])
+}
+
+func TestOpponentModelPredict(t *testing.T) {
+	snake := newTestSnake("them", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1})
+	board := Board{Width: 7, Height: 7, Food: []Coord{{3, 6}}, Snakes: []Battlesnake{snake}}
+	target := Coord{6, 3}
+
+	// never seen before: every safe move is as likely
+	var unseen *opponentModel
+	odds := unseen.predict(snake, board, target)
+	assert.Len(t, odds, 3)
+	for dir, p := range odds {
+		assert.InDelta(t, 1.0/3, p, 1e-9, "%v", dir)
+	}
+
+	// a snake that keeps heading for our head
+	hunter := &opponentModel{}
+	for i := 0; i < 10; i++ {
+		hunter.observe(snake, board, target, Direction_Right)
+	}
+	assert.Equal(t, 10, hunter.observed)
+	assert.Greater(t, hunter.rate(featureHead), 0.9)
+	assert.Less(t, hunter.rate(featureFood), 0.1)
+	odds = hunter.predict(snake, board, target)
+	assert.Greater(t, odds[Direction_Right], 0.9)
+	total := 0.0
+	for _, p := range odds {
+		total += p
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	// moves into a body aren't counted
+	hunter.observe(snake, board, target, Direction_Down)
+	assert.Equal(t, 10, hunter.observed)
+}
+
+func TestOpponentModelsObserve(t *testing.T) {
+	prev := newTestState(7, 7, []Coord{{3, 6}},
+		newTestSnake("me", 90, Coord{6, 3}, Coord{6, 2}, Coord{6, 1}),
+		newTestSnake("them", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}),
+	)
+	state := Step(prev, map[string]BattlesnakeMove{"me": BattlesnakeMove_Up, "them": BattlesnakeMove_Up})
+
+	models := opponentModels{}
+	models.observe(prev, state)
+	assert.Len(t, models, 1)
+	assert.Equal(t, 1, models["them"].observed)
+	assert.Equal(t, 1, models["them"].taken[featureFood])
+	assert.Equal(t, 0, models["them"].taken[featureHead])
+
+	clone := models.clone()
+	models.observe(prev, state)
+	assert.Equal(t, 1, clone["them"].observed)
+	assert.Equal(t, 2, models["them"].observed)
+}
+
+func TestCollisionWeightUsesModels(t *testing.T) {
+	me := newTestSnake("me", 90, Coord{1, 3}, Coord{0, 3}, Coord{0, 2})
+	them := newTestSnake("them", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}, Coord{3, 0})
+	board := Board{Width: 7, Height: 7, Food: []Coord{{3, 6}}, Snakes: []Battlesnake{me, them}}
+
+	chaser, grazer := &opponentModel{}, &opponentModel{}
+	for i := 0; i < 10; i++ {
+		chaser.observe(them, board, me.Head, Direction_Left)
+		grazer.observe(them, board, me.Head, Direction_Up)
+	}
+
+	logger := log.NewNopLogger()
+	uniform := collisionWeight(logger, Direction_Right, me, board, nil)
+	assert.InDelta(t, 2.0/3, uniform, 1e-9)
+	assert.Less(t, collisionWeight(logger, Direction_Right, me, board, opponentModels{"them": chaser}), uniform)
+	assert.Greater(t, collisionWeight(logger, Direction_Right, me, board, opponentModels{"them": grazer}), uniform)
+	// moving away from the longer snake is safe whatever it does
+	assert.Equal(t, 1.0, collisionWeight(logger, Direction_Up, me, board, opponentModels{"them": chaser}))
+}
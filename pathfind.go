@@ -0,0 +1,417 @@
+package main
+
+// This file contains the food pathfinder. Every food gets the shortest path from
+// the snake's head that doesn't run into a body still in the way when the head
+// gets there, found with A*, along with the fewest turns any opponent needs to
+// get to it. Food behind a wall of bodies only looks close and food an opponent
+// gets to first is gone by the time we arrive, so neither is worth heading to.
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/go-kit/log"
+)
+
+// foodUrgencyMargin is how many turns of health the snake is left with at the
+// food below which the path to it is taken over the searched move
+const foodUrgencyMargin = 2
+
+// foodPath is the shortest path from a snake's head to a food
+type foodPath struct {
+	food Coord
+	// path are the cells the head moves through, ending at the food
+	path []Coord
+	// first is the direction of the first step
+	first Direction
+	// rival is the fewest turns any opponent needs to get to the food, or never
+	rival int
+	// wins is set when the snake gets to the food before every opponent, or at
+	// the same time as opponents that are all shorter
+	wins bool
+}
+
+// turns returns back the number of turns it takes to get to the food
+func (p foodPath) turns() int {
+	return len(p.path)
+}
+
+// foodPlan are the paths from a snake's head to the food it can reach
+type foodPlan struct {
+	// span is the number of steps across the board
+	span  int
+	paths []foodPath
+}
+
+// planFood finds the paths from the snake's head to every food on the board and
+// who wins the race to each. Food is worthless when snakes grow every turn
+// anyway, so then there are no paths.
+func planFood(me Battlesnake, board Board) foodPlan {
+	span := board.Topology().Span()
+	plan := foodPlan{span: span.X + span.Y}
+	if board.ruleset.growsEveryTurn() || len(board.Food) == 0 || board.OutOfBounds(me.Head) {
+		return plan
+	}
+	bb := newBitBoard(withSnake(board, me))
+	freeAt := bb.freeAt()
+	rivals, rivalLengths := bb.rivalTurns(board.opponents(me.ID), freeAt)
+
+	for _, food := range board.Food {
+		if board.OutOfBounds(food) {
+			continue
+		}
+		path := bb.findPath(me.Head, food, me.Health, freeAt)
+		if len(path) == 0 {
+			continue
+		}
+		i := bb.index(food)
+		p := foodPath{
+			food:  food,
+			path:  path,
+			first: stepDirection(me.Head, path[0]),
+			rival: rivals[i],
+		}
+		p.wins = p.turns() < p.rival || (p.turns() == p.rival && me.Length > rivalLengths[i])
+		plan.paths = append(plan.paths, p)
+	}
+	return plan
+}
+
+// best returns back the shortest path to food the snake wins the race to, and
+// whether there is one
+func (plan foodPlan) best() (foodPath, bool) {
+	var best foodPath
+	found := false
+	for _, p := range plan.paths {
+		if p.wins && (!found || p.turns() < best.turns()) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// closeness is 1 for food that is right there, falling off to 0 for food a whole
+// span of the board away or further
+func (plan foodPlan) closeness(turns int) float64 {
+	return math.Max(0, 1-math.Pow(float64(turns)/float64(plan.span), 2))
+}
+
+// weight returns back the food availability of moving in the direction: how close
+// the nearest food the snake wins the race to is along a path starting that way,
+// or 0 if there's none
+func (plan foodPlan) weight(dir Direction) float64 {
+	weight := 0.0
+	for _, p := range plan.paths {
+		if p.wins && p.first == dir {
+			weight = math.Max(weight, plan.closeness(p.turns()))
+		}
+	}
+	return weight
+}
+
+// foodField is how many moves it takes to get to each food from every cell,
+// worked out once on the board a search starts from so that the states it
+// reaches only have to look their snakes' heads up rather than each run A*.
+// Bodies still there next turn are walls throughout, which they mostly stay for
+// the few turns a search looks ahead.
+type foodField struct {
+	bb *bitBoard
+	// span is the number of steps across the board
+	span int
+	// turns are, for every food, the fewest moves to it from each cell or never
+	turns map[Coord][]int
+}
+
+// newFoodField works out the moves to every food on the board from every cell.
+// Food is worthless when snakes grow every turn anyway, so then there is none.
+func newFoodField(board Board) *foodField {
+	span := board.Topology().Span()
+	field := &foodField{span: span.X + span.Y, turns: map[Coord][]int{}}
+	if board.ruleset.growsEveryTurn() || len(board.Food) == 0 {
+		return field
+	}
+	field.bb = newBitBoard(board)
+	freeAt := field.bb.freeAt()
+	for _, food := range board.Food {
+		if !board.OutOfBounds(food) {
+			field.turns[food] = field.bb.movesTo(food, freeAt)
+		}
+	}
+	return field
+}
+
+// closeness is how close the nearest food the snake wins the race to is, like
+// foodPlan.closeness, or 0 if there's none
+func (f *foodField) closeness(me Battlesnake, board Board) float64 {
+	if f.bb == nil || f.bb.outOfBounds(me.Head) {
+		return 0
+	}
+	opponents := board.opponents(me.ID)
+	nearest := never
+	for _, food := range board.Food {
+		turns, ok := f.turns[food]
+		if !ok {
+			continue
+		}
+		mine := turns[f.bb.index(me.Head)]
+		if mine >= nearest || mine >= int(me.Health) {
+			continue
+		}
+		wins := true
+		for _, other := range opponents {
+			if f.bb.outOfBounds(other.Head) {
+				continue
+			}
+			theirs := turns[f.bb.index(other.Head)]
+			if theirs < mine || (theirs == mine && other.Length >= me.Length) {
+				wins = false
+				break
+			}
+		}
+		if wins {
+			nearest = mine
+		}
+	}
+	if nearest == never {
+		return 0
+	}
+	return foodPlan{span: f.span}.closeness(nearest)
+}
+
+// foodField returns back the food field of the state a search started from, or
+// works one out for this state when it isn't part of one
+func (state GameState) foodField() *foodField {
+	if state.food != nil {
+		return state.food
+	}
+	return newFoodField(state.Board)
+}
+
+// foodCandidate returns back the first step of the best path to food as a move
+// scored by how close the food is, and whether the snake should take it over the
+// searched move: it barely has the health to get there and the step risks
+// neither a head-to-head nor a dead end
+func foodCandidate(logger log.Logger, state GameState, plan foodPlan) (pMove, bool) {
+	best, ok := plan.best()
+	if !ok {
+		return pMove{}, false
+	}
+	candidate := pMove{
+		dir:    directionToMove[best.first],
+		weight: plan.closeness(best.turns()),
+	}
+	if int(state.You.Health) > best.turns()+foodUrgencyMargin {
+		return candidate, false
+	}
+	next := state.You.Advance(best.first, state.Board)
+	if collisionWeight(logger, best.first, state.You, state.Board, state.opponents) < 1 ||
+		openSpaceOf(next, state.Board).deadEnd(len(next.Body)) {
+		return candidate, false
+	}
+	return candidate, true
+}
+
+// findPath returns back the cells of the shortest path from one cell to another,
+// or nil if there is none. A cell can only be moved onto once its body is gone,
+// see freeAt, and moving costs health like it does in Step.
+func (bb *bitBoard) findPath(from, to Coord, health int32, freeAt []int) []Coord {
+	cells := bb.width * bb.height
+	start, goal := bb.index(from), bb.index(to)
+	// reached is the turn each cell was first reached on, plus one so that zero
+	// is unreached
+	reached := make([]int, cells)
+	parent := make([]int, cells)
+	closed := make([]bool, cells)
+	reached[start] = 1
+	queue := &pathQueue{{cell: start, estimate: bb.distance(from, to), health: health}}
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(pathNode)
+		if node.cell == goal {
+			path := make([]Coord, node.turns)
+			for cell, i := goal, node.turns-1; i >= 0; cell, i = parent[cell], i-1 {
+				path[i] = bb.coord(cell)
+			}
+			return path
+		}
+		if closed[node.cell] {
+			continue
+		}
+		closed[node.cell] = true
+		c := bb.coord(node.cell)
+		turn := node.turns + 1
+		for _, dir := range directions {
+			n := bb.topology.Move(c, dir)
+			if bb.outOfBounds(n) {
+				continue
+			}
+			i := bb.index(n)
+			if closed[i] || (reached[i] != 0 && reached[i] <= turn+1) || freeAt[i] > turn {
+				continue
+			}
+			h := node.health - 1
+			if bb.food.has(i) {
+				h = SnakeMaxHealth
+			} else {
+				h -= bb.damage[i]
+			}
+			if h <= 0 {
+				continue
+			}
+			reached[i] = turn + 1
+			parent[i] = node.cell
+			heap.Push(queue, pathNode{cell: i, turns: turn, estimate: turn + bb.distance(n, to), health: h})
+		}
+	}
+	return nil
+}
+
+// timedDistances returns back the fewest turns it takes to get to each cell from
+// the given one, or never for cells out of reach, timed like findPath
+func (bb *bitBoard) timedDistances(from Coord, health int32, freeAt []int) []int {
+	cells := bb.width * bb.height
+	turns := make([]int, cells)
+	for i := range turns {
+		turns[i] = never
+	}
+	healthAt := make([]int32, cells)
+	start := bb.index(from)
+	turns[start] = 0
+	healthAt[start] = health
+	frontier := []int{start}
+	for turn := 1; len(frontier) > 0; turn++ {
+		next := []int{}
+		for _, cell := range frontier {
+			c := bb.coord(cell)
+			for _, dir := range directions {
+				n := bb.topology.Move(c, dir)
+				if bb.outOfBounds(n) {
+					continue
+				}
+				i := bb.index(n)
+				if turns[i] < turn || freeAt[i] > turn {
+					continue
+				}
+				h := healthAt[cell] - 1
+				if bb.food.has(i) {
+					h = SnakeMaxHealth
+				} else {
+					h -= bb.damage[i]
+				}
+				if h <= 0 {
+					continue
+				}
+				if turns[i] == turn {
+					if h > healthAt[i] {
+						healthAt[i] = h
+					}
+					continue
+				}
+				turns[i] = turn
+				healthAt[i] = h
+				next = append(next, i)
+			}
+		}
+		frontier = next
+	}
+	return turns
+}
+
+// movesTo returns back the fewest moves it takes to get to the cell from each
+// cell, or never for cells out of reach. Cells with a body still there next turn
+// can't be moved through, but a head on one can still move off it.
+func (bb *bitBoard) movesTo(to Coord, freeAt []int) []int {
+	cells := bb.width * bb.height
+	turns := make([]int, cells)
+	for i := range turns {
+		turns[i] = never
+	}
+	start := bb.index(to)
+	turns[start] = 0
+	frontier := []int{start}
+	for turn := 1; len(frontier) > 0; turn++ {
+		next := []int{}
+		for _, cell := range frontier {
+			c := bb.coord(cell)
+			for _, dir := range directions {
+				n := bb.topology.Move(c, dir)
+				if bb.outOfBounds(n) {
+					continue
+				}
+				i := bb.index(n)
+				if turns[i] != never {
+					continue
+				}
+				turns[i] = turn
+				if freeAt[i] <= 1 {
+					next = append(next, i)
+				}
+			}
+		}
+		frontier = next
+	}
+	return turns
+}
+
+// rivalTurns returns back the fewest turns any of the snakes needs to get to each
+// cell, timed like findPath, and the length of the longest snake getting there
+// then
+func (bb *bitBoard) rivalTurns(snakes []Battlesnake, freeAt []int) ([]int, []int32) {
+	cells := bb.width * bb.height
+	rivals := make([]int, cells)
+	lengths := make([]int32, cells)
+	for i := range rivals {
+		rivals[i] = never
+	}
+	for _, snake := range snakes {
+		if bb.outOfBounds(snake.Head) {
+			continue
+		}
+		turns := bb.timedDistances(snake.Head, snake.Health, freeAt)
+		for i, t := range turns {
+			if t < rivals[i] || (t == rivals[i] && snake.Length > lengths[i]) {
+				rivals[i], lengths[i] = t, snake.Length
+			}
+		}
+	}
+	return rivals, lengths
+}
+
+// distance returns back the number of moves between the cells ignoring bodies,
+// which A* needs to never overestimate
+func (bb *bitBoard) distance(from, to Coord) int {
+	d := bb.topology.Delta(from, to)
+	return abs(d.X) + abs(d.Y)
+}
+
+// pathNode is a cell A* has yet to move on from
+type pathNode struct {
+	cell  int
+	turns int
+	// estimate is turns plus the distance left to go
+	estimate int
+	health   int32
+}
+
+// pathQueue is a min-heap of nodes by estimate, breaking ties towards the node
+// furthest along
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].estimate != q[j].estimate {
+		return q[i].estimate < q[j].estimate
+	}
+	return q[i].turns > q[j].turns
+}
+
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wallSnake is a snake lying across column 3 of a 7x7 board from the bottom,
+// turning right at the top so the wall frees up from the top down
+func wallSnake() Battlesnake {
+	return newTestSnake("wall", 100,
+		Coord{3, 0}, Coord{3, 1}, Coord{3, 2}, Coord{3, 3}, Coord{3, 4}, Coord{3, 5},
+		Coord{4, 5}, Coord{5, 5}, Coord{6, 5},
+	)
+}
+
+func TestFindPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		state GameState
+		to    Coord
+		turns int
+	}{
+		{
+			name:  "open board",
+			state: newTestState(7, 7, nil, newTestSnake("me", 100, Coord{0, 0})),
+			to:    Coord{4, 2},
+			turns: 6,
+		},
+		{
+			name:  "around a wall that frees up",
+			state: newTestState(7, 7, nil, newTestSnake("me", 100, Coord{0, 0}), wallSnake()),
+			to:    Coord{6, 0},
+			turns: 12,
+		},
+		{
+			name:  "further than our health lasts",
+			state: newTestState(7, 7, nil, newTestSnake("me", 5, Coord{0, 0})),
+			to:    Coord{6, 6},
+			turns: 0,
+		},
+		{
+			name:  "through the only food in reach of our health",
+			state: newTestState(3, 3, []Coord{{1, 2}}, newTestSnake("me", 2, Coord{0, 2})),
+			to:    Coord{2, 0},
+			turns: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bb := newBitBoard(tt.state.Board)
+			freeAt := bb.freeAt()
+			path := bb.findPath(tt.state.You.Head, tt.to, tt.state.You.Health, freeAt)
+			assert.Len(t, path, tt.turns)
+			prev := tt.state.You.Head
+			for i, c := range path {
+				assert.Equal(t, 1, tt.state.Board.Manhattan(prev, c), "step %d", i)
+				assert.LessOrEqual(t, freeAt[bb.index(c)], i+1, "cell %v is still taken on turn %d", c, i+1)
+				prev = c
+			}
+			if tt.turns > 0 {
+				assert.Equal(t, tt.to, path[len(path)-1])
+			}
+		})
+	}
+}
+
+func TestPlanFood(t *testing.T) {
+	t.Run("food behind a wall", func(t *testing.T) {
+		// the food to the right is closer as the crow flies but the wall is in
+		// the way, so the food up top is the one to go for
+		state := newTestState(7, 7, []Coord{{4, 1}, {1, 6}},
+			newTestSnake("me", 100, Coord{1, 1}, Coord{1, 0}, Coord{0, 0}), wallSnake())
+		plan := planFood(state.You, state.Board)
+		best, ok := plan.best()
+		require.True(t, ok)
+		assert.Equal(t, Coord{1, 6}, best.food)
+		assert.Equal(t, 5, best.turns())
+		assert.Equal(t, Direction_Up, best.first)
+		assert.Greater(t, plan.weight(Direction_Up), plan.weight(Direction_Right))
+	})
+
+	t.Run("lost race", func(t *testing.T) {
+		state := newTestState(7, 7, []Coord{{3, 3}, {0, 6}},
+			newTestSnake("me", 100, Coord{0, 3}, Coord{0, 2}),
+			newTestSnake("other", 100, Coord{4, 3}, Coord{5, 3}),
+		)
+		plan := planFood(state.You, state.Board)
+		require.Len(t, plan.paths, 2)
+		assert.Equal(t, 1, plan.paths[0].rival)
+		assert.False(t, plan.paths[0].wins)
+		assert.True(t, plan.paths[1].wins)
+		best, ok := plan.best()
+		require.True(t, ok)
+		assert.Equal(t, Coord{0, 6}, best.food)
+		assert.Zero(t, plan.weight(Direction_Right))
+	})
+
+	tests := []struct {
+		name   string
+		length int
+		wins   bool
+	}{
+		{name: "tie against a shorter snake", length: 2, wins: true},
+		{name: "tie against a snake as long", length: 3, wins: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []Coord{{5, 3}, {6, 3}, {6, 2}}[:tt.length]
+			state := newTestState(7, 7, []Coord{{3, 3}},
+				newTestSnake("me", 100, Coord{1, 3}, Coord{0, 3}, Coord{0, 2}),
+				newTestSnake("other", 100, body...),
+			)
+			plan := planFood(state.You, state.Board)
+			require.Len(t, plan.paths, 1)
+			assert.Equal(t, 2, plan.paths[0].rival)
+			assert.Equal(t, tt.wins, plan.paths[0].wins)
+		})
+	}
+}
+
+func TestFoodField(t *testing.T) {
+	t.Run("food behind a wall", func(t *testing.T) {
+		state := newTestState(7, 7, []Coord{{4, 1}, {1, 6}},
+			newTestSnake("me", 100, Coord{1, 1}, Coord{1, 0}, Coord{0, 0}), wallSnake())
+		field := newFoodField(state.Board)
+		plan := planFood(state.You, state.Board)
+		best, ok := plan.best()
+		require.True(t, ok)
+		assert.InDelta(t, plan.closeness(best.turns()), field.closeness(state.You, state.Board), 1e-9)
+	})
+
+	t.Run("lost race", func(t *testing.T) {
+		state := newTestState(7, 7, []Coord{{3, 3}, {0, 6}},
+			newTestSnake("me", 100, Coord{0, 3}, Coord{0, 2}),
+			newTestSnake("other", 100, Coord{4, 3}, Coord{5, 3}),
+		)
+		field := newFoodField(state.Board)
+		assert.InDelta(t, planFood(state.You, state.Board).closeness(3), field.closeness(state.You, state.Board), 1e-9)
+	})
+
+	t.Run("states searched look up the root's field", func(t *testing.T) {
+		state := newTestState(7, 7, []Coord{{0, 5}, {0, 6}},
+			newTestSnake("me", 100, Coord{0, 3}, Coord{0, 2}),
+		)
+		state.food = newFoodField(state.Board)
+		next := Step(state, map[string]BattlesnakeMove{"me": BattlesnakeMove_Up})
+		assert.Same(t, state.food, next.foodField())
+		assert.InDelta(t, planFood(next.You, next.Board).closeness(1), next.food.closeness(next.You, next.Board), 1e-9)
+		// eaten food is gone from the board, so it's no longer looked up
+		next = Step(next, map[string]BattlesnakeMove{"me": BattlesnakeMove_Up})
+		assert.InDelta(t, planFood(next.You, next.Board).closeness(1), next.food.closeness(next.You, next.Board), 1e-9)
+		next = Step(next, map[string]BattlesnakeMove{"me": BattlesnakeMove_Up})
+		assert.Zero(t, next.food.closeness(next.You, next.Board))
+	})
+
+	t.Run("no food when growing every turn", func(t *testing.T) {
+		state := newTestState(7, 7, []Coord{{0, 5}},
+			newTestSnake("me", 100, Coord{0, 3}, Coord{0, 2}),
+		)
+		state.Board.ruleset = Ruleset{Name: rulesetConstrictor}
+		assert.Zero(t, newFoodField(state.Board).closeness(state.You, state.Board))
+	})
+}
+
+func TestFoodCandidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		health int32
+		urgent bool
+	}{
+		{name: "health to spare", health: 50, urgent: false},
+		{name: "running out", health: 5, urgent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newTestState(7, 7, []Coord{{3, 6}},
+				newTestSnake("me", tt.health, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}))
+			candidate, urgent := foodCandidate(log.NewNopLogger(), state, planFood(state.You, state.Board))
+			assert.Equal(t, BattlesnakeMove_Up, candidate.dir)
+			assert.InDelta(t, 1-9.0/196, candidate.weight, 1e-9)
+			assert.Equal(t, tt.urgent, urgent)
+		})
+	}
+}
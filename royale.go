@@ -0,0 +1,105 @@
+package main
+
+// This file contains support for the royale ruleset, where every few turns a
+// row or column along one side of the safe zone turns into hazard. The side is
+// picked at random by the engine, so the forecast assumes the worst: that every
+// side of the safe zone shrinks with each shrink.
+//
+// See https://docs.battlesnake.com/references/game-modes#royale
+
+const rulesetRoyale = "royale"
+
+// royaleForecastTurns is how many turns ahead the hazard is predicted
+const royaleForecastTurns = 10
+
+var royaleGameMode = gameMode{
+	Name:     rulesetRoyale,
+	Rules:    rules{Shrinks: true},
+	Strategy: defaultStrategy,
+}
+
+type RoyaleSettings struct {
+	ShrinkEveryNTurns int32 `json:"shrinkEveryNTurns"`
+}
+
+// safeZone is the rectangle of the board without hazard, bounds included. It is
+// empty when min is past max.
+type safeZone struct {
+	minX, maxX int
+	minY, maxY int
+}
+
+// safeZoneOf returns back the smallest rectangle holding every cell of the board
+// without hazard
+func safeZoneOf(board Board) safeZone {
+	hazards := map[Coord]bool{}
+	for _, h := range board.Hazards {
+		hazards[h] = true
+	}
+	zone := safeZone{minX: board.Width, maxX: -1, minY: board.Height, maxY: -1}
+	for x := 0; x < board.Width; x++ {
+		for y := 0; y < board.Height; y++ {
+			if hazards[Coord{x, y}] {
+				continue
+			}
+			if x < zone.minX {
+				zone.minX = x
+			}
+			if x > zone.maxX {
+				zone.maxX = x
+			}
+			if y < zone.minY {
+				zone.minY = y
+			}
+			if y > zone.maxY {
+				zone.maxY = y
+			}
+		}
+	}
+	return zone
+}
+
+func (z safeZone) contains(c Coord) bool {
+	return c.X >= z.minX && c.X <= z.maxX && c.Y >= z.minY && c.Y <= z.maxY
+}
+
+// shrink returns back the zone with n rows or columns taken off every side
+func (z safeZone) shrink(n int) safeZone {
+	return safeZone{minX: z.minX + n, maxX: z.maxX - n, minY: z.minY + n, maxY: z.maxY - n}
+}
+
+// shrinksWithin returns back how many times the safe zone shrinks over the
+// turns after the given turn. The engine shrinks it on every multiple of
+// shrinkEveryNTurns.
+func shrinksWithin(ruleset Ruleset, turn, turns int) int {
+	every := int(ruleset.Settings.Royale.ShrinkEveryNTurns)
+	if !ruleset.rules().Shrinks || every <= 0 {
+		return 0
+	}
+	return (turn+turns)/every - turn/every
+}
+
+// forecastHazards returns back the board with every cell that could become
+// hazard within the given number of turns added to its hazards. Boards of other
+// rulesets are returned back as they are.
+func forecastHazards(state GameState, turns int) Board {
+	board := state.Board
+	shrinks := shrinksWithin(board.ruleset, state.Turn, turns)
+	if shrinks == 0 {
+		return board
+	}
+
+	zone := safeZoneOf(board)
+	soon := zone.shrink(shrinks)
+	hazards := make([]Coord, len(board.Hazards), len(board.Hazards)+2*shrinks*(board.Width+board.Height))
+	copy(hazards, board.Hazards)
+	for x := zone.minX; x <= zone.maxX; x++ {
+		for y := zone.minY; y <= zone.maxY; y++ {
+			if c := (Coord{x, y}); !soon.contains(c) {
+				hazards = append(hazards, c)
+			}
+		}
+	}
+	board.Hazards = hazards
+	return board
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func royale(every int32) Ruleset {
+	return Ruleset{Name: rulesetRoyale, Settings: RulesetSettings{Royale: RoyaleSettings{ShrinkEveryNTurns: every}}}
+}
+
+func TestSafeZoneOf(t *testing.T) {
+	board := Board{Width: 5, Height: 5}
+	assert.Equal(t, safeZone{minX: 0, maxX: 4, minY: 0, maxY: 4}, safeZoneOf(board))
+
+	for i := 0; i < 5; i++ {
+		board.Hazards = append(board.Hazards, Coord{0, i}, Coord{i, 4})
+	}
+	zone := safeZoneOf(board)
+	assert.Equal(t, safeZone{minX: 1, maxX: 4, minY: 0, maxY: 3}, zone)
+	assert.True(t, zone.contains(Coord{1, 0}))
+	assert.False(t, zone.contains(Coord{0, 0}))
+}
+
+func TestShrinksWithin(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleset Ruleset
+		turn    int
+		turns   int
+		want    int
+	}{
+		{name: "standard never shrinks", ruleset: Ruleset{Name: "standard"}, turn: 4, turns: 10, want: 0},
+		{name: "royale without a cadence", ruleset: royale(0), turn: 4, turns: 10, want: 0},
+		{name: "on the next turn", ruleset: royale(5), turn: 4, turns: 1, want: 1},
+		{name: "just after a shrink", ruleset: royale(5), turn: 5, turns: 4, want: 0},
+		{name: "more than once", ruleset: royale(5), turn: 3, turns: 10, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shrinksWithin(tt.ruleset, tt.turn, tt.turns))
+		})
+	}
+}
+
+func TestForecastHazards(t *testing.T) {
+	state := newTestState(5, 5, nil, newTestSnake("me", 100, Coord{2, 2}))
+	state.Turn = 4
+	assert.Empty(t, forecastHazards(state, 1).Hazards)
+
+	state.Board.ruleset = royale(5)
+	board := forecastHazards(state, 1)
+	assert.Len(t, board.Hazards, 16)
+	assert.Equal(t, safeZone{minX: 1, maxX: 3, minY: 1, maxY: 3}, safeZoneOf(board))
+	assert.Empty(t, state.Board.Hazards, "original board should not be modified")
+}
+
+func TestEdgeWeightFollowsSafeZone(t *testing.T) {
+	me := newTestSnake("me", 100, Coord{2, 2}, Coord{2, 1})
+	board := Board{Width: 5, Height: 5, Snakes: []Battlesnake{me}}
+	assert.Equal(t, edgeWeight(Direction_Up, me, board), edgeWeight(Direction_Right, me, board))
+
+	for y := 0; y < 5; y++ {
+		board.Hazards = append(board.Hazards, Coord{0, y}, Coord{1, y})
+	}
+	assert.Greater(t, edgeWeight(Direction_Right, me, board), edgeWeight(Direction_Up, me, board))
+}
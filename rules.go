@@ -0,0 +1,131 @@
+package main
+
+// This file contains a local implementation of the standard Battlesnake ruleset
+// so that the rest of the logic can reason about what the board looks like after
+// every snake has moved.
+//
+// See https://docs.battlesnake.com/references/rules
+
+const (
+	// SnakeMaxHealth is the health a snake is restored to after eating
+	SnakeMaxHealth int32 = 100
+	// DefaultHazardDamagePerTurn is the damage the official engine deals for a
+	// hazard when the ruleset doesn't say otherwise
+	DefaultHazardDamagePerTurn int32 = 14
+)
+
+// Step advances the state by a single turn, applying the standard ruleset:
+//
+//  1. every snake moves simultaneously and drops its tail
+//  2. every snake loses one health
+//  3. snakes whose heads land on hazards without food take the hazard damage
+//  4. snakes whose heads land on food eat it, restoring health and growing
+//  5. snakes that starved, left the board or collided are eliminated
+//  6. in squad games, squads share eliminations, health and length
+//
+// Any snake without an entry in moves keeps moving in its current direction.
+// Eliminated snakes are removed from the board. Food is never spawned since
+// the official engine does so randomly.
+func Step(state GameState, moves map[string]BattlesnakeMove) GameState {
+	next := state
+	next.Turn = state.Turn + 1
+	next.Board.Food = make([]Coord, len(state.Board.Food))
+	copy(next.Board.Food, state.Board.Food)
+	next.Board.Hazards = make([]Coord, len(state.Board.Hazards))
+	copy(next.Board.Hazards, state.Board.Hazards)
+
+	snakes := make([]Battlesnake, len(state.Board.Snakes))
+	for i, snake := range state.Board.Snakes {
+		dir, ok := moveToDirection[moves[snake.ID]]
+		if !ok {
+			dir = defaultDirection(snake)
+		}
+		snakes[i] = snake.Advance(dir, state.Board)
+	}
+
+	next.Board.Food = uneatenFood(snakes, next.Board.Food)
+	next.Board.Snakes = eliminateSnakes(snakes, next.Board)
+	next.Board.Snakes = shareSquadAttributes(next.Board.ruleset, snakes, next.Board.Snakes)
+
+	you, ok := findSnake(state.You.ID, next.Board.Snakes)
+	if !ok {
+		you, _ = findSnake(state.You.ID, snakes)
+	}
+	next.You = you
+
+	return next
+}
+
+// Alive returns back whether the snake with the given ID is still on the board
+func (state GameState) Alive(id string) bool {
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultDirection is the direction the official engine moves a snake in when
+// it did not respond with a move: onwards, or up if it has no discernible direction
+func defaultDirection(snake Battlesnake) Direction {
+	if len(snake.Body) < 2 || snake.Body[0] == snake.Body[1] {
+		return Direction_Up
+	}
+	return stepDirection(snake.Body[1], snake.Body[0])
+}
+
+// uneatenFood returns back the food that no snake's head landed on
+func uneatenFood(snakes []Battlesnake, food []Coord) []Coord {
+	remaining := food[:0]
+	for _, f := range food {
+		eaten := false
+		for _, snake := range snakes {
+			if snake.Head == f {
+				eaten = true
+				break
+			}
+		}
+		if !eaten {
+			remaining = append(remaining, f)
+		}
+	}
+	return remaining
+}
+
+// eliminateSnakes returns back the snakes that survive this turn. Starvation
+// and leaving the board are resolved first; collisions are then checked only
+// against the snakes that are still on the board.
+func eliminateSnakes(snakes []Battlesnake, board Board) []Battlesnake {
+	candidates := make([]Battlesnake, 0, len(snakes))
+	for _, snake := range snakes {
+		if snake.Health <= 0 || board.OutOfBounds(snake.Head) {
+			continue
+		}
+		candidates = append(candidates, snake)
+	}
+
+	survivors := make([]Battlesnake, 0, len(candidates))
+	for _, snake := range candidates {
+		if !collided(snake, candidates, board.ruleset) {
+			survivors = append(survivors, snake)
+		}
+	}
+	return survivors
+}
+
+// collided returns back whether the snake's head ran into its own body, into
+// another snake's body, or into the head of a snake at least as long as itself.
+// Squadmates' bodies don't count when the ruleset allows squads to overlap.
+func collided(snake Battlesnake, snakes []Battlesnake, ruleset Ruleset) bool {
+	for _, other := range snakes {
+		passable := ruleset.Settings.Squad.AllowBodyCollisions && ruleset.squadmates(snake, other)
+		if !passable && CoordSliceContains(snake.Head, other.Body[1:]) {
+			return true
+		}
+		if other.ID != snake.ID && other.Head == snake.Head && len(snake.Body) <= len(other.Body) {
+			return true
+		}
+	}
+	return false
+}
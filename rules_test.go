@@ -0,0 +1,221 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		snakes     []Battlesnake
+		food       []Coord
+		hazards    []Coord
+		ruleset    Ruleset
+		moves      map[string]BattlesnakeMove
+		wantAlive  []string
+		wantBody   map[string][]Coord
+		wantHealth map[string]int32
+		wantFood   []Coord
+	}{
+		{
+			name: "moves and drops tail",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive: []string{"a"},
+			wantBody:  map[string][]Coord{"a": {{2, 3}, {2, 2}, {1, 2}}},
+		},
+		{
+			name: "eats and grows",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			food:      []Coord{{3, 2}, {0, 0}},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Right},
+			wantAlive: []string{"a"},
+			wantBody:  map[string][]Coord{"a": {{3, 2}, {2, 2}, {1, 2}, {1, 2}}},
+			wantFood:  []Coord{{0, 0}},
+		},
+		{
+			name: "missing move continues onwards",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			moves:     map[string]BattlesnakeMove{},
+			wantAlive: []string{"a"},
+			wantBody:  map[string][]Coord{"a": {{3, 2}, {2, 2}, {1, 2}}},
+		},
+		{
+			name: "starves",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 1, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive: []string{},
+		},
+		{
+			name: "eating on the last point of health survives",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 1, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			food:      []Coord{{2, 3}},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive: []string{"a"},
+		},
+		{
+			name: "hazard deals damage",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			hazards:    []Coord{{2, 3}},
+			moves:      map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive:  []string{"a"},
+			wantHealth: map[string]int32{"a": 35},
+		},
+		{
+			name: "hazard damage comes from the ruleset",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			hazards:    []Coord{{2, 3}},
+			ruleset:    Ruleset{Settings: RulesetSettings{HazardDamagePerTurn: damagePerTurn(30)}},
+			moves:      map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive:  []string{"a"},
+			wantHealth: map[string]int32{"a": 19},
+		},
+		{
+			name: "stacked hazards deal damage for each",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			hazards:    []Coord{{2, 3}, {2, 3}},
+			moves:      map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive:  []string{"a"},
+			wantHealth: map[string]int32{"a": 21},
+		},
+		{
+			name: "food in hazard heals",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			food:       []Coord{{2, 3}},
+			hazards:    []Coord{{2, 3}},
+			moves:      map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive:  []string{"a"},
+			wantHealth: map[string]int32{"a": SnakeMaxHealth},
+		},
+		{
+			name: "hazard eliminates",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 15, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}},
+			},
+			hazards:   []Coord{{2, 3}},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up},
+			wantAlive: []string{},
+		},
+		{
+			name: "wall",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{0, 2}, {1, 2}, {2, 2}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Left},
+			wantAlive: []string{},
+		},
+		{
+			name: "self collision",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {2, 3}, {3, 3}, {3, 2}, {3, 1}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Right},
+			wantAlive: []string{},
+		},
+		{
+			name: "following own tail is safe",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{2, 2}, {2, 3}, {3, 3}, {3, 2}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Right},
+			wantAlive: []string{"a"},
+		},
+		{
+			name: "body collision",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{1, 1}, {0, 1}, {0, 0}}},
+				{ID: "b", Health: 50, Body: []Coord{{3, 2}, {2, 2}, {1, 2}, {1, 3}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Right},
+			wantAlive: []string{"b"},
+		},
+		{
+			name: "head to head longer wins",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{1, 2}, {0, 2}, {0, 1}, {0, 0}}},
+				{ID: "b", Health: 50, Body: []Coord{{3, 2}, {4, 2}, {4, 1}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Right, "b": BattlesnakeMove_Left},
+			wantAlive: []string{"a"},
+		},
+		{
+			name: "head to head equal length eliminates both",
+			snakes: []Battlesnake{
+				{ID: "a", Health: 50, Body: []Coord{{1, 2}, {0, 2}, {0, 1}}},
+				{ID: "b", Health: 50, Body: []Coord{{3, 2}, {4, 2}, {4, 1}}},
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Right, "b": BattlesnakeMove_Left},
+			wantAlive: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.snakes {
+				tt.snakes[i].Head = tt.snakes[i].Body[0]
+				tt.snakes[i].Length = int32(len(tt.snakes[i].Body))
+			}
+			state := GameState{
+				Game:  Game{Ruleset: tt.ruleset},
+				Board: Board{Height: 5, Width: 5, Food: tt.food, Hazards: tt.hazards, Snakes: tt.snakes, ruleset: tt.ruleset},
+				You:   tt.snakes[0],
+			}
+
+			next := Step(state, tt.moves)
+
+			assert.Equal(t, state.Turn+1, next.Turn)
+			alive := []string{}
+			for _, snake := range next.Board.Snakes {
+				alive = append(alive, snake.ID)
+				assert.Equal(t, snake.Body[0], snake.Head)
+				assert.EqualValues(t, len(snake.Body), snake.Length)
+				if want, ok := tt.wantBody[snake.ID]; ok {
+					assert.Equal(t, want, snake.Body)
+				}
+				if want, ok := tt.wantHealth[snake.ID]; ok {
+					assert.Equal(t, want, snake.Health)
+				}
+			}
+			assert.ElementsMatch(t, tt.wantAlive, alive)
+			if tt.wantFood != nil {
+				assert.Equal(t, tt.wantFood, next.Board.Food)
+			}
+		})
+	}
+}
+
+func TestStepDoesNotMutate(t *testing.T) {
+	me := Battlesnake{ID: "a", Health: 50, Head: Coord{2, 2}, Length: 3, Body: []Coord{{2, 2}, {1, 2}, {0, 2}}}
+	state := GameState{
+		Board: Board{Height: 5, Width: 5, Food: []Coord{{3, 2}}, Snakes: []Battlesnake{me}},
+		You:   me,
+	}
+
+	next := Step(state, map[string]BattlesnakeMove{"a": BattlesnakeMove_Right})
+
+	assert.Equal(t, []Coord{{2, 2}, {1, 2}, {0, 2}}, state.Board.Snakes[0].Body)
+	assert.Equal(t, []Coord{{3, 2}}, state.Board.Food)
+	assert.EqualValues(t, 50, state.You.Health)
+	assert.EqualValues(t, SnakeMaxHealth, next.You.Health)
+	assert.True(t, next.Alive("a"))
+}
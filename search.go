@@ -0,0 +1,207 @@
+package main
+
+// This file contains the anytime search framework used by move. Searches are run
+// with increasing depth until the per-game timeout (minus a latency margin) runs
+// out, and the result of the deepest completed search is used.
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cameron-Kurotori/battlesnake/logging"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// defaultTimeout is the timeout used when the game does not specify one
+	defaultTimeout = 500 * time.Millisecond
+	// minSearchBudget is the least amount of time given to a search regardless of
+	// how small the timeout is compared to the latency margin
+	minSearchBudget = 10 * time.Millisecond
+)
+
+type searchAlgorithm string
+
+const (
+	// algorithmHeuristic only scores the moves we can make this turn
+	algorithmHeuristic searchAlgorithm = "heuristic"
+	// algorithmParanoid searches assuming every opponent plays against us
+	algorithmParanoid searchAlgorithm = "paranoid"
+	// algorithmMaxN searches assuming every snake plays for itself
+	algorithmMaxN searchAlgorithm = "maxn"
+	// algorithmMCTS plays the game out many times from the current state
+	algorithmMCTS searchAlgorithm = "mcts"
+)
+
+type searchConfig struct {
+	Algorithm searchAlgorithm
+	// LatencyMargin is subtracted from the game's timeout to leave time for the
+	// response to travel back to the game engine
+	LatencyMargin time.Duration
+	// MaxDepth caps iterative deepening
+	MaxDepth int
+	// RolloutPolicy is the name of the rollout policy used by MCTS
+	RolloutPolicy string
+}
+
+var searchSettings = newSearchConfig()
+
+// newSearchConfig builds the search configuration from the environment:
+//
+//	SEARCH_ALGORITHM: one of heuristic, paranoid, maxn or mcts (default paranoid)
+//	LATENCY_MARGIN_MS: milliseconds held back from the game timeout (default 150)
+//	MAX_SEARCH_DEPTH: the deepest iteration that will be searched (default 32)
+//	ROLLOUT_POLICY: one of random, floodfill or heuristic (default floodfill)
+func newSearchConfig() searchConfig {
+	config := searchConfig{
+		Algorithm:     algorithmParanoid,
+		LatencyMargin: 150 * time.Millisecond,
+		MaxDepth:      32,
+		RolloutPolicy: rolloutFloodFill,
+	}
+	switch algorithm := searchAlgorithm(strings.ToLower(os.Getenv("SEARCH_ALGORITHM"))); algorithm {
+	case algorithmHeuristic, algorithmParanoid, algorithmMaxN, algorithmMCTS:
+		config.Algorithm = algorithm
+	case "":
+	default:
+		_ = level.Warn(logging.GlobalLogger()).Log("msg", "ignoring unknown search algorithm", "algorithm", algorithm)
+	}
+	if policy := strings.ToLower(os.Getenv("ROLLOUT_POLICY")); len(policy) > 0 {
+		if _, ok := rolloutPolicies[policy]; ok {
+			config.RolloutPolicy = policy
+		} else {
+			_ = level.Warn(logging.GlobalLogger()).Log("msg", "ignoring unknown rollout policy", "policy", policy)
+		}
+	}
+	if ms, ok := envInt("LATENCY_MARGIN_MS"); ok && ms >= 0 {
+		config.LatencyMargin = time.Duration(ms) * time.Millisecond
+	}
+	if depth, ok := envInt("MAX_SEARCH_DEPTH"); ok && depth > 0 {
+		config.MaxDepth = depth
+	}
+	return config
+}
+
+// envInt returns back the integer value of the environment variable and whether
+// it was set to a valid integer
+func envInt(name string) (int, bool) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return 0, false
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		_ = level.Warn(logging.GlobalLogger()).Log("msg", "ignoring invalid integer environment variable", "name", name, "value", value, "err", err)
+		return 0, false
+	}
+	return i, true
+}
+
+// searchDeadline returns back the time by which the search has to finish for the
+// response to reach the game engine within the game's timeout
+func searchDeadline(start time.Time, state GameState, margin time.Duration) time.Time {
+	timeout := time.Duration(state.Game.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	budget := timeout - margin
+	if budget < minSearchBudget {
+		budget = minSearchBudget
+	}
+	return start.Add(budget)
+}
+
+// newDepthSearch returns back the search for the state using the configured
+// algorithm. Once only two snakes are left the duel search is used instead of the
+// multiplayer one, and a snake on its own plays the solo strategy.
+func newDepthSearch(logger log.Logger, state GameState, config searchConfig) depthSearch {
+	weights := gameModeFor(state.Board.Ruleset()).Strategy.Eval
+	// the states searched look their distances to food up in the root's field
+	state.food = newFoodField(state.Board)
+	if solo(state) {
+		return soloDepthSearch(logger, state)
+	}
+	switch config.Algorithm {
+	case algorithmParanoid, algorithmMaxN:
+		if len(state.Board.Snakes) == 2 && state.Alive(state.You.ID) && len(state.Board.opponents(state.You.ID)) == 1 {
+			return duelDepthSearch(logger, state, weights)
+		}
+		return multiplayerDepthSearch(logger, state, config.Algorithm, weights)
+	case algorithmMCTS:
+		return mctsDepthSearch(logger, state, config.RolloutPolicy)
+	}
+	return func(ctx context.Context, depth int) (searchResult, error) {
+		return heuristicResult(logger, state), nil
+	}
+}
+
+type searchResult struct {
+	move  BattlesnakeMove
+	score float64
+	depth int
+	// complete is set when the search saw the whole game tree, so searching any
+	// deeper would not change the result
+	complete bool
+	// proven is set when the search proved how the game ends
+	proven proof
+	// scores are the exact scores of our moves at the root, for the searches that
+	// have them. Moves shown to lose or draw may be missing.
+	scores map[BattlesnakeMove]float64
+	// keyvals are logged along with the move that is made
+	keyvals []interface{}
+}
+
+// survives returns back whether the search scored the move without finding that
+// it loses or draws the game. A search that doesn't score moves vouches for none.
+func (r searchResult) survives(move BattlesnakeMove) bool {
+	score, ok := r.scores[move]
+	return ok && score > drawScore+provenMargin
+}
+
+// doomed returns back whether the search found every move loses: it proved the
+// game lost, or every move it scored is a proven loss
+func (r searchResult) doomed() bool {
+	if r.proven == provenLoss {
+		return true
+	}
+	if len(r.scores) == 0 {
+		return false
+	}
+	for _, score := range r.scores {
+		if proofOf(score) != provenLoss {
+			return false
+		}
+	}
+	return true
+}
+
+// depthSearch searches to the given depth. It should regularly check the context
+// and return back its error as soon as it is done.
+type depthSearch func(ctx context.Context, depth int) (searchResult, error)
+
+// iterativeDeepening runs the search at increasing depths until the context is
+// done, maxDepth is reached or the search is complete. It returns back the result
+// of the deepest search that finished and false if not even the first one did.
+func iterativeDeepening(ctx context.Context, logger log.Logger, maxDepth int, search depthSearch) (searchResult, bool) {
+	var best searchResult
+	found := false
+	for depth := 1; depth <= maxDepth; depth++ {
+		if ctx.Err() != nil {
+			break
+		}
+		result, err := search(ctx, depth)
+		if err != nil {
+			_ = level.Debug(logger).Log("msg", "search interrupted", "depth", depth, "err", err)
+			break
+		}
+		best, found = result, true
+		if result.complete {
+			break
+		}
+	}
+	return best, found
+}
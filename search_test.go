@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchDeadline(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name    string
+		timeout int32
+		margin  time.Duration
+		want    time.Duration
+	}{
+		{"subtracts margin", 500, 150 * time.Millisecond, 350 * time.Millisecond},
+		{"defaults timeout", 0, 100 * time.Millisecond, 400 * time.Millisecond},
+		{"keeps a minimum budget", 100, 150 * time.Millisecond, minSearchBudget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := GameState{Game: Game{Timeout: tt.timeout}}
+			assert.Equal(t, start.Add(tt.want), searchDeadline(start, state, tt.margin))
+		})
+	}
+}
+
+func TestSearchResultDoomed(t *testing.T) {
+	tests := []struct {
+		name   string
+		result searchResult
+		want   bool
+	}{
+		{"proven lost", searchResult{proven: provenLoss}, true},
+		{"nothing scored", searchResult{}, false},
+		{
+			name:   "every move lost",
+			result: searchResult{scores: map[BattlesnakeMove]float64{BattlesnakeMove_Left: lossScore + 1, BattlesnakeMove_Right: lossScore + 3}},
+			want:   true,
+		},
+		{
+			name:   "one move survives",
+			result: searchResult{scores: map[BattlesnakeMove]float64{BattlesnakeMove_Left: lossScore + 1, BattlesnakeMove_Right: 0}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.result.doomed())
+		})
+	}
+}
+
+func TestIterativeDeepening(t *testing.T) {
+	t.Run("returns the deepest completed search", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		result, ok := iterativeDeepening(ctx, log.NewNopLogger(), 10, func(ctx context.Context, depth int) (searchResult, error) {
+			if depth == 3 {
+				cancel()
+				return searchResult{}, ctx.Err()
+			}
+			return searchResult{move: BattlesnakeMove_Up, depth: depth}, nil
+		})
+		assert.True(t, ok)
+		assert.Equal(t, 2, result.depth)
+	})
+
+	t.Run("stops when complete", func(t *testing.T) {
+		calls := 0
+		result, ok := iterativeDeepening(context.Background(), log.NewNopLogger(), 10, func(ctx context.Context, depth int) (searchResult, error) {
+			calls++
+			return searchResult{depth: depth, complete: depth == 4}, nil
+		})
+		assert.True(t, ok)
+		assert.Equal(t, 4, result.depth)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("stops at max depth", func(t *testing.T) {
+		result, ok := iterativeDeepening(context.Background(), log.NewNopLogger(), 3, func(ctx context.Context, depth int) (searchResult, error) {
+			return searchResult{depth: depth}, nil
+		})
+		assert.True(t, ok)
+		assert.Equal(t, 3, result.depth)
+	})
+
+	t.Run("nothing completed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, ok := iterativeDeepening(ctx, log.NewNopLogger(), 3, func(ctx context.Context, depth int) (searchResult, error) {
+			return searchResult{depth: depth}, nil
+		})
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+// This file contains the session store, which remembers each game between
+// requests. A session is created when the game starts, updated with every move
+// and removed when the game ends. The engine doesn't always get to send the end
+// of a game, so sessions that haven't been seen for a while are evicted whenever
+// the store is changed. The same server can play several snakes in one game, so
+// every snake gets a session of its own.
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionTTL is how long a session is kept after the last request for its game
+const sessionTTL = 10 * time.Minute
+
+// sessionKey identifies the session of one of our snakes in a game
+type sessionKey struct {
+	gameID  string
+	snakeID string
+}
+
+// sessionKeyOf returns back the key of the session of the snake the state is for
+func sessionKeyOf(state GameState) sessionKey {
+	return sessionKey{gameID: state.Game.ID, snakeID: state.You.ID}
+}
+
+// session is what is remembered about a game between requests
+type session struct {
+	started time.Time
+	// lastSeen is when the last request for the game came in
+	lastSeen time.Time
+	// last is the state of the last move, nil before the first one
+	last *GameState
+	// moves are the moves we made, by turn
+	moves map[int]BattlesnakeMove
+	// opponents are the models of how the opponents move
+	opponents opponentModels
+}
+
+func newSession(now time.Time) *session {
+	return &session{
+		started:   now,
+		lastSeen:  now,
+		moves:     map[int]BattlesnakeMove{},
+		opponents: opponentModels{},
+	}
+}
+
+// sessionStore holds the session of every snake in every game being played. It
+// is safe to use from concurrent requests.
+type sessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	sessions map[sessionKey]*session
+}
+
+var sessions = newSessionStore(sessionTTL)
+
+func newSessionStore(ttl time.Duration) *sessionStore {
+	return &sessionStore{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: map[sessionKey]*session{},
+	}
+}
+
+// start creates a new session, replacing any there was, and evicts the sessions
+// that have expired
+func (s *sessionStore) start(key sessionKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evict(now)
+	s.sessions[key] = newSession(now)
+}
+
+// update runs the function on the session while nothing else can use it, and
+// evicts the sessions that have expired. A session that wasn't started, or that
+// expired, is started over.
+func (s *sessionStore) update(key sessionKey, fn func(*session)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evict(now)
+	sess, ok := s.sessions[key]
+	if !ok {
+		sess = newSession(now)
+		s.sessions[key] = sess
+	}
+	sess.lastSeen = now
+	fn(sess)
+}
+
+// end removes the session, returning it back if there was one, and evicts the
+// sessions that have expired
+func (s *sessionStore) end(key sessionKey) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[key]
+	delete(s.sessions, key)
+	s.evict(s.now())
+	return sess, ok
+}
+
+// len returns back the number of sessions held, expired or not
+func (s *sessionStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+func (s *sessionStore) expired(sess *session, now time.Time) bool {
+	return now.Sub(sess.lastSeen) > s.ttl
+}
+
+// evict removes every expired session, the store has to be locked
+func (s *sessionStore) evict(now time.Time) {
+	for key, sess := range s.sessions {
+		if s.expired(sess, now) {
+			delete(s.sessions, key)
+		}
+	}
+}
+
+// observe learns from what the opponents did since the last move, which is only
+// possible if that was the turn before
+func (sess *session) observe(state GameState) {
+	if sess.last != nil && sess.last.Turn+1 == state.Turn {
+		sess.opponents.observe(*sess.last, state)
+	}
+}
+
+// record remembers the state of the turn and the move we made on it
+func (sess *session) record(state GameState, move BattlesnakeMove) {
+	sess.last = &state
+	sess.moves[state.Turn] = move
+}
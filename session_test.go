@@ -0,0 +1,201 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock for the session store that only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestSessionStore(ttl time.Duration) (*sessionStore, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	store := newSessionStore(ttl)
+	store.now = clock.Now
+	return store, clock
+}
+
+// testKey returns back the key of our snake's session in the game
+func testKey(gameID string) sessionKey {
+	return sessionKey{gameID: gameID, snakeID: "me"}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	store, clock := newTestSessionStore(time.Minute)
+	store.start(testKey("game"))
+	for turn := 0; turn < 3; turn++ {
+		clock.Advance(time.Second)
+		state := GameState{Game: Game{ID: "game"}, Turn: turn}
+		store.update(testKey("game"), func(sess *session) {
+			if turn == 0 {
+				assert.Nil(t, sess.last)
+				assert.Empty(t, sess.moves)
+			}
+			sess.record(state, BattlesnakeMove_Up)
+		})
+	}
+
+	sess, ok := store.end(testKey("game"))
+	require.True(t, ok)
+	assert.Equal(t, 2, sess.last.Turn)
+	assert.Len(t, sess.moves, 3)
+	assert.Equal(t, time.Unix(0, 0), sess.started)
+	assert.Equal(t, time.Unix(3, 0), sess.lastSeen)
+	_, ok = store.end(testKey("game"))
+	assert.False(t, ok)
+}
+
+func TestSessionUpdateWithoutStart(t *testing.T) {
+	store, _ := newTestSessionStore(time.Minute)
+	store.update(testKey("game"), func(sess *session) {
+		sess.record(GameState{Turn: 3}, BattlesnakeMove_Up)
+	})
+	sess, ok := store.end(testKey("game"))
+	require.True(t, ok)
+	assert.Equal(t, BattlesnakeMove_Up, sess.moves[3])
+}
+
+func TestSessionStartReplaces(t *testing.T) {
+	store, _ := newTestSessionStore(time.Minute)
+	store.update(testKey("game"), func(sess *session) {
+		sess.record(GameState{Turn: 3}, BattlesnakeMove_Up)
+	})
+	store.start(testKey("game"))
+	sess, ok := store.end(testKey("game"))
+	require.True(t, ok)
+	assert.Empty(t, sess.moves)
+}
+
+func TestSessionPerSnake(t *testing.T) {
+	// two of our snakes in the same game keep apart what they remember
+	store, _ := newTestSessionStore(time.Minute)
+	first := sessionKey{gameID: "game", snakeID: "first"}
+	second := sessionKey{gameID: "game", snakeID: "second"}
+	store.start(first)
+	store.start(second)
+	store.update(first, func(sess *session) {
+		sess.record(GameState{Turn: 0}, BattlesnakeMove_Up)
+	})
+	store.update(second, func(sess *session) {
+		sess.record(GameState{Turn: 0}, BattlesnakeMove_Down)
+	})
+	assert.Equal(t, 2, store.len())
+	sess, ok := store.end(second)
+	require.True(t, ok)
+	assert.Equal(t, BattlesnakeMove_Down, sess.moves[0])
+	assert.Equal(t, 1, store.len())
+	sess, ok = store.end(first)
+	require.True(t, ok)
+	assert.Equal(t, BattlesnakeMove_Up, sess.moves[0])
+}
+
+func TestSessionEviction(t *testing.T) {
+	store, clock := newTestSessionStore(time.Minute)
+	store.start(testKey("abandoned"))
+	store.start(testKey("idle"))
+	clock.Advance(2 * time.Minute)
+
+	// expired sessions are kept until the store is changed
+	assert.Equal(t, 2, store.len())
+
+	// starts, moves and ends all evict, so games that never end don't pile up
+	store.start(testKey("new"))
+	assert.Equal(t, 1, store.len())
+	store.start(testKey("stuck"))
+	clock.Advance(2 * time.Minute)
+	store.update(testKey("playing"), func(*session) {})
+	assert.Equal(t, 1, store.len())
+	store.start(testKey("stuck"))
+	clock.Advance(2 * time.Minute)
+	store.end(testKey("other"))
+	assert.Equal(t, 0, store.len())
+
+	// a game kept going isn't evicted
+	store.start(testKey("playing"))
+	for i := 0; i < 3; i++ {
+		clock.Advance(30 * time.Second)
+		store.update(testKey("playing"), func(sess *session) {
+			sess.record(GameState{Turn: i}, BattlesnakeMove_Up)
+		})
+	}
+	assert.Equal(t, 1, store.len())
+
+	// an expired game that comes back starts over
+	clock.Advance(2 * time.Minute)
+	store.update(testKey("playing"), func(sess *session) {
+		assert.Nil(t, sess.last)
+	})
+}
+
+func TestSessionStoreConcurrent(t *testing.T) {
+	store, _ := newTestSessionStore(time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			store.start(testKey(id))
+			for turn := 0; turn < 50; turn++ {
+				state := GameState{Game: Game{ID: id}, Turn: turn}
+				store.update(testKey(id), func(sess *session) {
+					sess.record(state, BattlesnakeMove_Left)
+				})
+			}
+		}(string(rune('a' + g)))
+	}
+	wg.Wait()
+	assert.Equal(t, 8, store.len())
+	for g := 0; g < 8; g++ {
+		sess, ok := store.end(testKey(string(rune('a' + g))))
+		require.True(t, ok)
+		assert.Len(t, sess.moves, 50)
+	}
+}
+
+func TestMoveUpdatesSession(t *testing.T) {
+	state := newTestState(7, 7, nil,
+		newTestSnake("me", 90, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}),
+		newTestSnake("them", 90, Coord{5, 5}, Coord{5, 4}, Coord{5, 3}),
+	)
+	state.Game.ID = "TestMoveUpdatesSession"
+	state.Turn = 4
+	start(state)
+	response := move(state)
+	sessions.update(sessionKeyOf(state), func(sess *session) {
+		require.NotNil(t, sess.last)
+		assert.Equal(t, 4, sess.last.Turn)
+		assert.Equal(t, response.Move, sess.moves[4])
+		assert.Empty(t, sess.opponents)
+	})
+
+	// the opponent's move is learnt from on the next turn
+	next := Step(state, map[string]BattlesnakeMove{"me": response.Move, "them": BattlesnakeMove_Up})
+	next.Game.ID = state.Game.ID
+	move(next)
+	sessions.update(sessionKeyOf(state), func(sess *session) {
+		assert.Len(t, sess.moves, 2)
+		assert.Equal(t, 1, sess.opponents["them"].observed)
+	})
+	end(state)
+	_, ok := sessions.end(sessionKeyOf(state))
+	assert.False(t, ok)
+}
@@ -0,0 +1,367 @@
+package main
+
+// This file contains the strategy for solo games, where ours is the only snake on
+// the board and the only goal is to survive as long as possible. The snake
+// follows a cycle through the board with its tail, which it can keep doing
+// forever, and only heads for food once it is getting hungry since every food
+// eaten makes the snake longer and the board tighter.
+//
+// A move off the cycle is a shortcut. Shortcuts are taken while hungry as long
+// as the body still lies along the cycle behind the new head, so the snake can
+// go back to following its tail.
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+)
+
+// soloHealthMargin is how much health the snake keeps spare on top of the
+// distance to the nearest food before it goes to eat
+const soloHealthMargin = 10
+
+// soloFullHealth is the most health the snake needs to have left once at food to
+// pass it by. A lap of a large board's cycle takes more turns than the snake has
+// health, so there waiting a lap out is never possible and the snake settles for
+// having plenty left.
+const soloFullHealth = int(SnakeMaxHealth / 2)
+
+// hunger is how badly the snake needs to eat
+type hunger int
+
+const (
+	// full snakes stay away from food
+	full hunger = iota
+	// hungry snakes take shortcuts off the cycle towards food
+	hungry
+	// starving snakes will starve unless they take the shortest way to food
+	starving
+)
+
+// solo returns back whether ours is the only snake on the board
+func solo(state GameState) bool {
+	return len(state.Board.Snakes) == 1 && state.Alive(state.You.ID) && !state.Board.OutOfBounds(state.You.Head)
+}
+
+// soloDepthSearch returns back a depthSearch that makes the solo move. It
+// doesn't look ahead so a single depth is complete.
+func soloDepthSearch(logger log.Logger, state GameState) depthSearch {
+	return func(ctx context.Context, depth int) (searchResult, error) {
+		move, hunger := soloMove(state)
+		return searchResult{
+			move:     move,
+			depth:    depth,
+			complete: true,
+			keyvals:  []interface{}{"search", "solo", "hunger", hunger},
+		}, nil
+	}
+}
+
+// soloCycle is a cycle through the cells of the board, every cell adjacent to
+// the next
+type soloCycle struct {
+	cells []Coord
+	// position is the index of each cell on the cycle
+	position map[Coord]int
+}
+
+// hamiltonianCycle returns back a cycle through the cells of a board of the
+// given size. The cycle goes through every cell unless both sides are odd, where
+// there is no such cycle and the top right corner is left out. Boards that are
+// a single cell across have no cycle at all.
+func hamiltonianCycle(width, height int) []Coord {
+	if width < 2 || height < 2 {
+		return nil
+	}
+	if height%2 == 1 && width%2 == 0 {
+		cycle := hamiltonianCycle(height, width)
+		for i, c := range cycle {
+			cycle[i] = Coord{c.Y, c.X}
+		}
+		return cycle
+	}
+
+	// along the bottom row, then back and forth over the rows above leaving the
+	// left column free to come back down. With an odd number of rows the top row
+	// is picked up in pairs from the last row across.
+	rows := height - height%2
+	cycle := make([]Coord, 0, width*height)
+	for x := 0; x < width; x++ {
+		cycle = append(cycle, Coord{x, 0})
+	}
+	for y := 1; y < rows; y++ {
+		if y%2 == 0 {
+			for x := 1; x < width; x++ {
+				cycle = append(cycle, Coord{x, y})
+			}
+			continue
+		}
+		for x := width - 1; x >= 1; x-- {
+			cycle = append(cycle, Coord{x, y})
+			if y == rows-1 && rows < height && x%2 == 1 {
+				cycle = append(cycle, Coord{x, y + 1}, Coord{x - 1, y + 1})
+			}
+		}
+	}
+	for y := rows - 1; y >= 1; y-- {
+		cycle = append(cycle, Coord{0, y})
+	}
+	return cycle
+}
+
+// newSoloCycle returns back the cycle through the board. A cell left out of the
+// cycle is a detour between two neighbours two steps apart on it, so it takes
+// the place of the cell skipped over.
+func newSoloCycle(board Board) soloCycle {
+	cycle := soloCycle{
+		cells:    hamiltonianCycle(board.Width, board.Height),
+		position: map[Coord]int{},
+	}
+	for i, c := range cycle.cells {
+		cycle.position[c] = i
+	}
+	if len(cycle.cells) == 0 {
+		return cycle
+	}
+	for x := 0; x < board.Width; x++ {
+		for y := 0; y < board.Height; y++ {
+			c := Coord{x, y}
+			if _, ok := cycle.position[c]; ok {
+				continue
+			}
+			for _, dir := range directions {
+				from, ok := cycle.position[board.Topology().Move(c, dir)]
+				if !ok {
+					continue
+				}
+				for _, other := range directions {
+					if to, ok := cycle.position[board.Topology().Move(c, other)]; ok && cycle.ahead(from, to) == 2 {
+						cycle.position[c] = (from + 1) % len(cycle.cells)
+					}
+				}
+			}
+		}
+	}
+	return cycle
+}
+
+// ahead returns back how many steps along the cycle it takes to get from one
+// position to another
+func (c soloCycle) ahead(from, to int) int {
+	return (to - from + len(c.cells)) % len(c.cells)
+}
+
+// allows returns back whether the snake can move its head to the cell and still
+// follow the cycle afterwards: every segment has to lie on the cycle between the
+// tail and the new head, leaving enough room in front of the head for the tail
+// to move out of the way
+func (c soloCycle) allows(snake Battlesnake, to Coord, eats bool) bool {
+	head, ok := c.position[to]
+	if !ok {
+		return false
+	}
+	tailCoord := snake.Body[len(snake.Body)-1]
+	tail, ok := c.position[tailCoord]
+	if !ok {
+		return false
+	}
+	stacked := 0
+	for i, segment := range snake.Body {
+		pos, ok := c.position[segment]
+		if !ok || c.ahead(tail, pos) >= c.ahead(tail, head) {
+			return false
+		}
+		if i > 0 && i < len(snake.Body)-1 && segment == tailCoord {
+			stacked++
+		}
+	}
+	growth := stacked
+	if eats {
+		growth++
+	}
+	return c.ahead(head, tail) > growth
+}
+
+// foodDistances returns back the number of moves from each cell of the board to
+// the nearest food, going around bodies, or never if no food can be reached
+func foodDistances(board Board) []int {
+	bb := newBitBoard(board)
+	dist := make([]int, board.Width*board.Height)
+	for i := range dist {
+		dist[i] = never
+	}
+	frontier := []int{}
+	for _, f := range board.Food {
+		if bb.outOfBounds(f) {
+			continue
+		}
+		dist[bb.index(f)] = 0
+		frontier = append(frontier, bb.index(f))
+	}
+	for len(frontier) > 0 {
+		next := []int{}
+		for _, cell := range frontier {
+			for _, dir := range directions {
+				n := bb.topology.Move(bb.coord(cell), dir)
+				if bb.outOfBounds(n) {
+					continue
+				}
+				i := bb.index(n)
+				if dist[i] != never || bb.blocked(i) {
+					continue
+				}
+				dist[i] = dist[cell] + 1
+				next = append(next, i)
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
+
+// foodAhead returns back the fewest moves from the cell to food going only
+// forward along the cycle and fewer than limit steps along it, which keeps the
+// body behind the head the whole way, or never if there is no such food
+func (c soloCycle) foodAhead(board Board, from Coord, limit int) int {
+	start, ok := c.position[from]
+	if !ok {
+		return never
+	}
+	food := make(map[Coord]bool, len(board.Food))
+	for _, f := range board.Food {
+		food[f] = true
+	}
+	topology := board.Topology()
+	dist := map[Coord]int{from: 0}
+	frontier := []Coord{from}
+	for len(frontier) > 0 {
+		next := []Coord{}
+		for _, a := range frontier {
+			if food[a] {
+				return dist[a]
+			}
+			for _, dir := range directions {
+				b := topology.Move(a, dir)
+				pos, ok := c.position[b]
+				if !ok || topology.OutOfBounds(b) {
+					continue
+				}
+				if _, seen := dist[b]; seen {
+					continue
+				}
+				step := c.ahead(start, pos)
+				if step <= c.ahead(start, c.position[a]) || step >= limit {
+					continue
+				}
+				dist[b] = dist[a] + 1
+				next = append(next, b)
+			}
+		}
+		frontier = next
+	}
+	return never
+}
+
+// soloOption is a move the snake could make in a solo game
+type soloOption struct {
+	move BattlesnakeMove
+	// survives is set when the move doesn't lead into a dead end
+	survives bool
+	// onCycle is set when the snake can keep following the cycle after the move
+	onCycle bool
+	eats    bool
+	// food is the number of moves to the nearest food after the move, and
+	// cycleFood the number when only going forward along the cycle
+	food, cycleFood int
+	// ahead is how far along the cycle the move takes the head
+	ahead int
+}
+
+// better returns back whether the option is better than the other one for a
+// snake that is as hungry as given
+func (o soloOption) better(other soloOption, h hunger) bool {
+	switch {
+	case o.survives != other.survives:
+		return o.survives
+	case h < starving && o.onCycle != other.onCycle:
+		return o.onCycle
+	case h == full && o.eats != other.eats:
+		return !o.eats
+	case h == hungry && o.cycleFood != other.cycleFood:
+		return o.cycleFood < other.cycleFood
+	case h > full && o.food != other.food:
+		return o.food < other.food
+	}
+	return o.ahead < other.ahead
+}
+
+// soloMove returns back the move that keeps our snake alive the longest in a
+// solo game along with how hungry it is
+func soloMove(state GameState) (BattlesnakeMove, hunger) {
+	me, board := state.You, state.Board
+	cycle := newSoloCycle(board)
+	dist := foodDistances(board)
+	bb := newBitBoard(board)
+	tail, tailOnCycle := cycle.position[me.Body[len(me.Body)-1]]
+
+	options := []soloOption{}
+	nearest, cycleNearest := never, never
+	for _, m := range candidateMoves(me, board) {
+		next := me.Advance(moveToDirection[m], board)
+		option := soloOption{
+			move:      m,
+			food:      never,
+			cycleFood: never,
+			ahead:     never,
+		}
+		if !board.OutOfBounds(next.Head) {
+			option.eats = bb.food.has(bb.index(next.Head))
+			option.food = dist[bb.index(next.Head)]
+			option.survives = !board.Occupied(next.Head) && !openSpaceOf(next, board).deadEnd(len(next.Body))
+		}
+		if len(cycle.cells) > 0 && tailOnCycle {
+			option.onCycle = cycle.allows(me, next.Head, option.eats)
+			to, toOK := cycle.position[next.Head]
+			if from, ok := cycle.position[me.Head]; ok && toOK {
+				option.ahead = cycle.ahead(from, to)
+			}
+			if option.onCycle {
+				option.cycleFood = cycle.foodAhead(board, next.Head, cycle.ahead(to, tail))
+			}
+		}
+		if option.food < nearest {
+			nearest = option.food
+		}
+		if option.cycleFood < cycleNearest {
+			cycleNearest = option.cycleFood
+		}
+		options = append(options, option)
+	}
+
+	// food is only passed by when there is the health to go a whole way around the
+	// cycle and come back to it, or plenty of health on boards too large for that.
+	// Food that can only be reached by leaving the cycle is only worth going for
+	// once the snake is getting hungry anyway.
+	lap := soloHealthMargin + len(cycle.cells)
+	if lap > soloFullHealth {
+		lap = soloFullHealth
+	}
+	h := full
+	switch {
+	case nearest == never:
+	case int(me.Health)-cycleNearest-1 > lap:
+	case int(me.Health)-cycleNearest-1 > 0:
+		h = hungry
+	case int(me.Health)-nearest-1 <= soloHealthMargin:
+		h = starving
+	default:
+		h = hungry
+	}
+	best := options[0]
+	for _, option := range options[1:] {
+		if option.better(best, h) {
+			best = option
+		}
+	}
+	return best.move, h
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHamiltonianCycle(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantLen       int
+	}{
+		{2, 2, 4},
+		{3, 3, 8},
+		{4, 5, 20},
+		{5, 4, 20},
+		{7, 6, 42},
+		{7, 7, 48},
+		{11, 11, 120},
+		{19, 19, 360},
+		{1, 5, 0},
+	}
+	for _, tt := range tests {
+		cycle := hamiltonianCycle(tt.width, tt.height)
+		assert.Len(t, cycle, tt.wantLen, "%dx%d", tt.width, tt.height)
+
+		board := Board{Width: tt.width, Height: tt.height}
+		seen := map[Coord]bool{}
+		for i, c := range cycle {
+			assert.False(t, board.OutOfBounds(c), "%dx%d %v", tt.width, tt.height, c)
+			assert.False(t, seen[c], "%dx%d %v twice", tt.width, tt.height, c)
+			seen[c] = true
+			next := cycle[(i+1)%len(cycle)]
+			assert.Equal(t, 1, board.Manhattan(c, next), "%dx%d %v to %v", tt.width, tt.height, c, next)
+		}
+	}
+}
+
+func TestSoloMove(t *testing.T) {
+	tests := []struct {
+		name       string
+		health     int32
+		food       []Coord
+		want       BattlesnakeMove
+		wantHunger hunger
+	}{
+		{
+			name:       "follows the cycle",
+			health:     100,
+			want:       BattlesnakeMove_Right,
+			wantHunger: full,
+		},
+		{
+			name:       "stays away from food while full",
+			health:     100,
+			food:       []Coord{{4, 0}},
+			want:       BattlesnakeMove_Up,
+			wantHunger: full,
+		},
+		{
+			name:       "takes a shortcut to food when hungry",
+			health:     12,
+			food:       []Coord{{3, 3}},
+			want:       BattlesnakeMove_Up,
+			wantHunger: hungry,
+		},
+		{
+			name:       "follows the cycle to food when hungry",
+			health:     12,
+			food:       []Coord{{6, 0}},
+			want:       BattlesnakeMove_Right,
+			wantHunger: hungry,
+		},
+		{
+			name:       "goes straight for food when starving",
+			health:     2,
+			food:       []Coord{{3, 2}},
+			want:       BattlesnakeMove_Up,
+			wantHunger: starving,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newTestState(7, 7, tt.food,
+				newTestSnake("me", tt.health, Coord{3, 0}, Coord{2, 0}, Coord{1, 0}),
+			)
+			move, h := soloMove(state)
+			assert.Equal(t, tt.want, move)
+			assert.Equal(t, tt.wantHunger, h)
+		})
+	}
+}
+
+func TestSoloMoveFullOnLargeBoard(t *testing.T) {
+	// a lap of the cycle takes longer than the snake's health lasts, which mustn't
+	// keep it from being full and passing food by
+	state := newTestState(11, 11, []Coord{{4, 0}},
+		newTestSnake("me", 100, Coord{3, 0}, Coord{2, 0}, Coord{1, 0}),
+	)
+	move, h := soloMove(state)
+	assert.Equal(t, BattlesnakeMove_Up, move)
+	assert.Equal(t, full, h)
+}
+
+// playSolo plays a solo game with the solo strategy for the given number of
+// turns, placing a new food at random whenever there is none left, and returns
+// back the final state
+func playSolo(seed int64, width, height, turns int) GameState {
+	rng := rand.New(rand.NewSource(seed))
+	state := newTestState(width, height, nil,
+		newTestSnake("me", SnakeMaxHealth, Coord{1, 1}, Coord{1, 1}, Coord{1, 1}),
+	)
+	for turn := 0; turn < turns && solo(state); turn++ {
+		if len(state.Board.Food) == 0 {
+			free := []Coord{}
+			for x := 0; x < width; x++ {
+				for y := 0; y < height; y++ {
+					if !state.Board.Occupied(Coord{x, y}) {
+						free = append(free, Coord{x, y})
+					}
+				}
+			}
+			if len(free) == 0 {
+				break
+			}
+			state.Board.Food = []Coord{free[rng.Intn(len(free))]}
+		}
+		move, _ := soloMove(state)
+		state = Step(state, map[string]BattlesnakeMove{state.You.ID: move})
+	}
+	return state
+}
+
+func TestSoloSurvives(t *testing.T) {
+	tests := []struct {
+		width, height int
+	}{
+		{7, 7},
+		{8, 8},
+		{11, 11},
+	}
+	for _, tt := range tests {
+		for seed := int64(0); seed < 3; seed++ {
+			state := playSolo(seed, tt.width, tt.height, 600)
+			assert.True(t, state.Alive("me"), "%dx%d seed %d died on turn %d", tt.width, tt.height, seed, state.Turn)
+		}
+	}
+}
+
+func TestHeuristicMovesSolo(t *testing.T) {
+	state := newTestState(11, 11, []Coord{{5, 5}},
+		newTestSnake("me", 80, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}),
+	)
+	moves := heuristicMoves(log.NewNopLogger(), state)
+	assert.NotEmpty(t, moves)
+	for _, m := range moves {
+		assert.False(t, math.IsNaN(m.weight), m.dir)
+		assert.Greater(t, m.weight, 0.0, m.dir)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+// This file contains support for the squad ruleset, where snakes play in teams
+// given by Battlesnake.Squad and the game is won by the last squad standing.
+// Depending on the settings squadmates can pass through each other's bodies and
+// share eliminations, health and length.
+//
+// See https://docs.battlesnake.com/references/game-modes#squads
+
+const rulesetSquad = "squad"
+
+var squadGameMode = gameMode{
+	Name:     rulesetSquad,
+	Rules:    rules{Squads: true},
+	Strategy: defaultStrategy,
+}
+
+type SquadSettings struct {
+	AllowBodyCollisions bool `json:"allowBodyCollisions"`
+	SharedElimination   bool `json:"sharedElimination"`
+	SharedHealth        bool `json:"sharedHealth"`
+	SharedLength        bool `json:"sharedLength"`
+}
+
+// squadmates returns back whether the snakes are different snakes on the same
+// squad in a squad game
+func (r Ruleset) squadmates(a, b Battlesnake) bool {
+	return r.rules().squadmates(a, b)
+}
+
+func (r rules) squadmates(a, b Battlesnake) bool {
+	return r.Squads && a.Squad != "" && a.Squad == b.Squad && a.ID != b.ID
+}
+
+// opponents returns back the snakes on the board that are neither the snake
+// with the given ID nor on its squad
+func (b Board) opponents(id string) []Battlesnake {
+	me, _ := findSnake(id, b.Snakes)
+	me.ID = id
+	opponents := []Battlesnake{}
+	for _, snake := range b.Snakes {
+		if snake.ID != id && !b.ruleset.squadmates(me, snake) {
+			opponents = append(opponents, snake)
+		}
+	}
+	return opponents
+}
+
+// team returns back the snakes on the board on the same squad as the snake with
+// the given ID, the snake itself included
+func (b Board) team(id string) []Battlesnake {
+	me, ok := findSnake(id, b.Snakes)
+	if !ok {
+		return []Battlesnake{}
+	}
+	team := []Battlesnake{me}
+	for _, snake := range b.Snakes {
+		if b.ruleset.squadmates(me, snake) {
+			team = append(team, snake)
+		}
+	}
+	return team
+}
+
+// shareSquadAttributes applies the squad settings to the snakes that survived
+// the turn out of the ones that moved: squads that lost a snake lose every
+// snake, and the rest take on the best health and length on their squad
+func shareSquadAttributes(ruleset Ruleset, moved, survivors []Battlesnake) []Battlesnake {
+	settings := ruleset.Settings.Squad
+	if !ruleset.rules().Squads {
+		return survivors
+	}
+
+	if settings.SharedElimination {
+		lost := map[string]bool{}
+		for _, snake := range moved {
+			if _, ok := findSnake(snake.ID, survivors); !ok && snake.Squad != "" {
+				lost[snake.Squad] = true
+			}
+		}
+		remaining := survivors[:0]
+		for _, snake := range survivors {
+			if snake.Squad == "" || !lost[snake.Squad] {
+				remaining = append(remaining, snake)
+			}
+		}
+		survivors = remaining
+	}
+
+	health := map[string]int32{}
+	length := map[string]int{}
+	for _, snake := range survivors {
+		if snake.Health > health[snake.Squad] {
+			health[snake.Squad] = snake.Health
+		}
+		if len(snake.Body) > length[snake.Squad] {
+			length[snake.Squad] = len(snake.Body)
+		}
+	}
+	for i, snake := range survivors {
+		if snake.Squad == "" {
+			continue
+		}
+		if settings.SharedHealth {
+			survivors[i].Health = health[snake.Squad]
+		}
+		if settings.SharedLength {
+			body := snake.Body
+			for len(body) < length[snake.Squad] {
+				body = append(body, body[len(body)-1])
+			}
+			survivors[i].Body = body
+			survivors[i].Length = int32(len(body))
+		}
+	}
+	return survivors
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func squadRuleset(settings SquadSettings) Ruleset {
+	return Ruleset{Name: rulesetSquad, Settings: RulesetSettings{Squad: settings}}
+}
+
+func squadSnake(id, squad string, health int32, body ...Coord) Battlesnake {
+	snake := newTestSnake(id, health, body...)
+	snake.Squad = squad
+	return snake
+}
+
+func TestSquadStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   SquadSettings
+		snakes     []Battlesnake
+		moves      map[string]BattlesnakeMove
+		wantAlive  []string
+		wantHealth map[string]int32
+		wantLength map[string]int
+	}{
+		{
+			name: "squadmate bodies block by default",
+			snakes: []Battlesnake{
+				squadSnake("a", "red", 50, Coord{1, 1}, Coord{0, 1}, Coord{0, 0}),
+				squadSnake("b", "red", 50, Coord{3, 2}, Coord{2, 2}, Coord{1, 2}, Coord{1, 3}),
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Right},
+			wantAlive: []string{"b"},
+		},
+		{
+			name:     "squadmate bodies can be passed through",
+			settings: SquadSettings{AllowBodyCollisions: true},
+			snakes: []Battlesnake{
+				squadSnake("a", "red", 50, Coord{1, 1}, Coord{0, 1}, Coord{0, 0}),
+				squadSnake("b", "red", 50, Coord{3, 2}, Coord{2, 2}, Coord{1, 2}, Coord{1, 3}),
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Right},
+			wantAlive: []string{"a", "b"},
+		},
+		{
+			name:     "other squads still block",
+			settings: SquadSettings{AllowBodyCollisions: true},
+			snakes: []Battlesnake{
+				squadSnake("a", "red", 50, Coord{1, 1}, Coord{0, 1}, Coord{0, 0}),
+				squadSnake("b", "blue", 50, Coord{3, 2}, Coord{2, 2}, Coord{1, 2}, Coord{1, 3}),
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Right},
+			wantAlive: []string{"b"},
+		},
+		{
+			name:     "shared elimination",
+			settings: SquadSettings{SharedElimination: true},
+			snakes: []Battlesnake{
+				squadSnake("a", "red", 50, Coord{0, 1}, Coord{1, 1}, Coord{2, 1}),
+				squadSnake("b", "red", 50, Coord{3, 3}, Coord{3, 2}, Coord{3, 1}),
+				squadSnake("c", "blue", 50, Coord{0, 4}, Coord{1, 4}, Coord{2, 4}),
+			},
+			moves:     map[string]BattlesnakeMove{"a": BattlesnakeMove_Left, "b": BattlesnakeMove_Up, "c": BattlesnakeMove_Down},
+			wantAlive: []string{"c"},
+		},
+		{
+			name:     "shared health and length",
+			settings: SquadSettings{SharedHealth: true, SharedLength: true},
+			snakes: []Battlesnake{
+				squadSnake("a", "red", 50, Coord{0, 1}, Coord{0, 0}),
+				squadSnake("b", "red", 20, Coord{3, 2}, Coord{3, 1}, Coord{3, 0}),
+				squadSnake("c", "blue", 10, Coord{1, 4}, Coord{2, 4}, Coord{3, 4}, Coord{4, 4}),
+			},
+			moves:      map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Up, "c": BattlesnakeMove_Down},
+			wantAlive:  []string{"a", "b", "c"},
+			wantHealth: map[string]int32{"a": 49, "b": 49, "c": 9},
+			wantLength: map[string]int{"a": 3, "b": 3, "c": 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newTestState(5, 5, nil, tt.snakes...)
+			state.Game.Ruleset = squadRuleset(tt.settings)
+			state.Board.ruleset = state.Game.Ruleset
+
+			next := Step(state, tt.moves)
+
+			alive := []string{}
+			for _, snake := range next.Board.Snakes {
+				alive = append(alive, snake.ID)
+				if want, ok := tt.wantHealth[snake.ID]; ok {
+					assert.Equal(t, want, snake.Health, snake.ID)
+				}
+				if want, ok := tt.wantLength[snake.ID]; ok {
+					assert.Len(t, snake.Body, want, snake.ID)
+					assert.EqualValues(t, want, snake.Length, snake.ID)
+				}
+			}
+			assert.ElementsMatch(t, tt.wantAlive, alive)
+		})
+	}
+}
+
+func TestOpponentsAndTeam(t *testing.T) {
+	board := Board{Width: 5, Height: 5, ruleset: squadRuleset(SquadSettings{}), Snakes: []Battlesnake{
+		squadSnake("a", "red", 50, Coord{0, 0}),
+		squadSnake("b", "red", 50, Coord{1, 1}),
+		squadSnake("c", "blue", 50, Coord{2, 2}),
+	}}
+	ids := func(snakes []Battlesnake) []string {
+		ids := []string{}
+		for _, snake := range snakes {
+			ids = append(ids, snake.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"c"}, ids(board.opponents("a")))
+	assert.Equal(t, []string{"a", "b"}, ids(board.team("a")))
+
+	board.ruleset = Ruleset{Name: "standard"}
+	assert.Equal(t, []string{"b", "c"}, ids(board.opponents("a")))
+	assert.Equal(t, []string{"a"}, ids(board.team("a")))
+}
+
+func TestSquadmatesAreNotThreats(t *testing.T) {
+	me := squadSnake("me", "red", 90, Coord{2, 2}, Coord{2, 1}, Coord{2, 0})
+	mate := squadSnake("mate", "red", 90, Coord{2, 4}, Coord{3, 4}, Coord{4, 4}, Coord{4, 3})
+	board := Board{Width: 7, Height: 7, Snakes: []Battlesnake{me, mate}}
+
+	board.ruleset = Ruleset{Name: "standard"}
+	assert.Less(t, otherSnakeWeight(board.comparator(Direction_Up), me, board), 1.0)
+	assert.Less(t, collisionWeight(log.NewNopLogger(), Direction_Up, me, board, nil), 1.0)
+
+	board.ruleset = squadRuleset(SquadSettings{})
+	assert.Equal(t, 1.0, otherSnakeWeight(board.comparator(Direction_Up), me, board))
+
+	board.ruleset = squadRuleset(SquadSettings{AllowBodyCollisions: true})
+	assert.Equal(t, 1.0, collisionWeight(log.NewNopLogger(), Direction_Up, me, board, nil))
+}
@@ -0,0 +1,90 @@
+package main
+
+// This file contains the cut-off tactics. An opponent whose territory is small
+// for its length is worth trying to seal in: a short sequence of our moves that
+// walls its head into less room than it needs leaves it with nowhere to go. Our
+// body can only seal off cells we get to before any opponent, since an opponent
+// getting there first would slip through, so every cell of the sequence has to
+// be won like the race to food is.
+
+const (
+	// cutoffDepth is the most moves a cut-off is looked for in
+	cutoffDepth = 3
+	// cutoffTerritory is how many times its length an opponent's territory can be
+	// for it to be worth trying to seal in
+	cutoffTerritory = 2
+)
+
+// cutoff is a sequence of our moves that seals an opponent into a dead end
+type cutoff struct {
+	moves []Direction
+	// target is the ID of the opponent sealed in
+	target string
+	// area is the number of cells the opponent is left with
+	area int
+}
+
+// findCutoff returns back the shortest sequence of our moves that seals an
+// opponent in, and whether there is one. Out of sequences as short the one
+// leaving the opponent the least room wins. The opponents are assumed to stay
+// put while we move, which only matters within the cells we win the race to.
+func findCutoff(state GameState) (cutoff, bool) {
+	board := state.Board
+	if board.OutOfBounds(state.You.Head) {
+		return cutoff{}, false
+	}
+	opponents := board.opponents(state.You.ID)
+	territories := voronoi(board).territories
+	targets := []Battlesnake{}
+	for _, snake := range opponents {
+		if board.OutOfBounds(snake.Head) || territories[snake.ID].cells >= cutoffTerritory*len(snake.Body) {
+			continue
+		}
+		// an opponent that's sealed in already needs no help
+		if openSpaceOf(snake, board).deadEnd(len(snake.Body)) {
+			continue
+		}
+		targets = append(targets, snake)
+	}
+	if len(targets) == 0 {
+		return cutoff{}, false
+	}
+
+	bb := newBitBoard(board)
+	rivals, rivalLengths := bb.rivalTurns(opponents, bb.freeAt())
+
+	var best cutoff
+	found := false
+	var search func(me Battlesnake, board Board, moves []Direction)
+	search = func(me Battlesnake, board Board, moves []Direction) {
+		if len(moves) > 0 && !openSpaceOf(me, board).deadEnd(len(me.Body)) {
+			for _, target := range targets {
+				space := openSpaceOf(target, board)
+				if !space.deadEnd(len(target.Body)) {
+					continue
+				}
+				if !found || len(moves) < len(best.moves) || (len(moves) == len(best.moves) && space.area < best.area) {
+					best = cutoff{moves: moves, target: target.ID, area: space.area}
+					found = true
+				}
+			}
+		}
+		if len(moves) == cutoffDepth || (found && len(moves) >= len(best.moves)) {
+			return
+		}
+		turn := len(moves) + 1
+		for _, dir := range directions {
+			next, nextBoard, ok := advanceAlone(me, dir, board)
+			if !ok {
+				continue
+			}
+			i := bb.index(next.Head)
+			if rivals[i] < turn || (rivals[i] == turn && rivalLengths[i] >= next.Length) {
+				continue
+			}
+			search(next, nextBoard, append(moves[:len(moves):len(moves)], dir))
+		}
+	}
+	search(state.You, board, nil)
+	return best, found
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCutoff(t *testing.T) {
+	// our body runs up column 2 and along the bottom, and the prey is heading
+	// down the left edge into the pocket beside it
+	me := newTestSnake("me", 100,
+		Coord{2, 3}, Coord{2, 2}, Coord{2, 1}, Coord{2, 0}, Coord{3, 0},
+		Coord{4, 0}, Coord{5, 0}, Coord{6, 0}, Coord{6, 1}, Coord{6, 2},
+	)
+	prey := newTestSnake("prey", 100, Coord{0, 2}, Coord{0, 3}, Coord{0, 4}, Coord{0, 5}, Coord{0, 6}, Coord{1, 6})
+
+	tests := []struct {
+		name  string
+		state GameState
+		found bool
+		want  cutoff
+	}{
+		{
+			name:  "seal the pocket",
+			state: newTestState(7, 7, nil, me, prey),
+			found: true,
+			want:  cutoff{moves: []Direction{Direction_Left}, target: "prey", area: 5},
+		},
+		{
+			name: "prey too far from the pocket",
+			state: newTestState(7, 7, nil, me,
+				newTestSnake("prey", 100, Coord{4, 4}, Coord{4, 5}, Coord{4, 6}, Coord{5, 6}, Coord{6, 6}, Coord{6, 5})),
+			found: false,
+		},
+		{
+			// the prey would win the head-on at the mouth, so the pocket is
+			// sealed further up where we get first
+			name: "longer prey next to the mouth",
+			state: newTestState(7, 7, nil, me,
+				newTestSnake("prey", 100,
+					Coord{1, 2}, Coord{0, 2}, Coord{0, 3}, Coord{0, 4}, Coord{0, 5}, Coord{0, 6},
+					Coord{1, 6}, Coord{2, 6}, Coord{3, 6}, Coord{4, 6}, Coord{5, 6},
+				)),
+			found: true,
+			want:  cutoff{moves: []Direction{Direction_Up, Direction_Up}, target: "prey", area: 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cut, found := findCutoff(tt.state)
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.want, cut)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+// This file contains the tail-chasing safety mode. Once the space the snake can
+// reach is smaller than it is, the only room that keeps opening up is what its
+// own tail leaves behind, so the snake should keep its head where it can still
+// get to its tail. Each move is checked over a few simulated turns, with the
+// opponents staying put, for how long some way of moving on keeps the tail in
+// reach.
+
+import (
+	"github.com/go-kit/log"
+)
+
+// tailChaseTurns is how many turns ahead a move is checked for keeping the tail
+// in reach
+const tailChaseTurns = 4
+
+// tailChase is the move that keeps the tail in reach the longest
+type tailChase struct {
+	move BattlesnakeMove
+	// turns are, for every move that doesn't leave the board or run into a body,
+	// how many turns the tail can be kept in reach for after making it
+	turns map[BattlesnakeMove]int
+}
+
+// chaseTail returns back the move that keeps the tail in reach the longest when
+// the snake is cramped, and whether it is. Out of moves keeping the tail in reach
+// as long the one ending up closest to the tail wins.
+func chaseTail(logger log.Logger, state GameState) (tailChase, bool) {
+	me := state.You
+	if state.Board.OutOfBounds(me.Head) || numOpenSpaces(logger, me, state.Board) >= int(me.Length) {
+		return tailChase{}, false
+	}
+	chase := tailChase{turns: map[BattlesnakeMove]int{}}
+	found := false
+	closest := never
+	for _, dir := range directions {
+		turns, ok := tailTurns(me, dir, state.Board, tailChaseTurns)
+		if !ok {
+			continue
+		}
+		move := directionToMove[dir]
+		chase.turns[move] = turns
+		if turns == 0 {
+			continue
+		}
+		next := me.Advance(dir, state.Board)
+		distance := state.Board.Manhattan(next.Head, next.Body[len(next.Body)-1])
+		if !found || turns > chase.turns[chase.move] || (turns == chase.turns[chase.move] && distance < closest) {
+			chase.move, closest, found = move, distance, true
+		}
+	}
+	return chase, found
+}
+
+// tailTurns returns back how many of the given number of turns some way of
+// moving keeps the tail in reach for, starting with a move in the direction, and
+// false if that move leaves the board, runs into a body or starves the snake
+func tailTurns(me Battlesnake, dir Direction, board Board, turns int) (int, bool) {
+	next, nextBoard, ok := advanceAlone(me, dir, board)
+	if !ok {
+		return 0, false
+	}
+	if !tailInReach(next, nextBoard) {
+		return 0, true
+	}
+	best := 0
+	if turns > 1 {
+		for _, d := range directions {
+			if t, ok := tailTurns(next, d, nextBoard, turns-1); ok && t > best {
+				best = t
+				if best == turns-1 {
+					break
+				}
+			}
+		}
+	}
+	return 1 + best, true
+}
+
+// advanceAlone moves the snake in the direction with everyone else staying put,
+// returning back the snake and board after and false if the move leaves the
+// board, runs into a body or starves the snake
+func advanceAlone(me Battlesnake, dir Direction, board Board) (Battlesnake, Board, bool) {
+	next := me.Advance(dir, board)
+	if board.OutOfBounds(next.Head) || board.Occupied(next.Head) || next.Health <= 0 {
+		return next, board, false
+	}
+	return next, withSnake(board, next), true
+}
+
+// tailInReach returns back whether the snake's head is next to its tail or has a
+// path to it
+func tailInReach(me Battlesnake, board Board) bool {
+	tail := me.Body[len(me.Body)-1]
+	if tail == me.Head || board.Manhattan(me.Head, tail) == 1 {
+		return true
+	}
+	bb := newBitBoard(board)
+	return bb.findPath(me.Head, tail, me.Health, bb.freeAt()) != nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaseTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   GameState
+		cramped bool
+		want    BattlesnakeMove
+	}{
+		{
+			name:    "room to spare",
+			state:   newTestState(5, 5, nil, newTestSnake("me", 100, Coord{2, 2}, Coord{2, 1}, Coord{2, 0})),
+			cramped: false,
+		},
+		{
+			// the snake fills the board and only its tail ever moves out of the way
+			name: "filling the board",
+			state: newTestState(4, 2, nil, newTestSnake("me", 100,
+				Coord{0, 0}, Coord{0, 1}, Coord{1, 1}, Coord{2, 1}, Coord{3, 1}, Coord{3, 0}, Coord{2, 0}, Coord{1, 0})),
+			cramped: true,
+			want:    BattlesnakeMove_Right,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chase, cramped := chaseTail(log.NewNopLogger(), tt.state)
+			require.Equal(t, tt.cramped, cramped)
+			if tt.cramped {
+				assert.Equal(t, tt.want, chase.move)
+				assert.Equal(t, tailChaseTurns, chase.turns[chase.move])
+			}
+		})
+	}
+}
+
+func TestTailTurns(t *testing.T) {
+	// the head can go into the corner on its right or onto its tail on its left
+	state := newTestState(4, 3, nil, newTestSnake("me", 100,
+		Coord{2, 0}, Coord{2, 1}, Coord{3, 1}, Coord{3, 2}, Coord{2, 2},
+		Coord{1, 2}, Coord{0, 2}, Coord{0, 1}, Coord{1, 1}, Coord{1, 0}))
+	tests := []struct {
+		dir   Direction
+		turns int
+	}{
+		{dir: Direction_Left, turns: tailChaseTurns},
+		{dir: Direction_Right, turns: 0},
+	}
+	for _, tt := range tests {
+		turns, ok := tailTurns(state.You, tt.dir, state.Board, tailChaseTurns)
+		require.True(t, ok)
+		assert.Equal(t, tt.turns, turns, "dir %v", tt.dir)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+// This file contains the shapes a board can have. The standard board has walls
+// around it while in the wrapped ruleset the board is a torus: leaving one edge
+// enters the opposite one. Working out where a move leads or how far apart two
+// cells are goes through the board's Topology so the rest of the logic doesn't
+// have to care which it is.
+//
+// See https://docs.battlesnake.com/references/game-modes#wrapped
+
+import (
+	"math"
+)
+
+const rulesetWrapped = "wrapped"
+
+var wrappedGameMode = gameMode{
+	Name:     rulesetWrapped,
+	Rules:    rules{Wrapped: true},
+	Strategy: defaultStrategy,
+}
+
+type Topology interface {
+	// OutOfBounds returns back whether the coordinate is off the board
+	OutOfBounds(c Coord) bool
+	// Move returns back the coordinate one step from c in the direction
+	Move(c Coord, dir Direction) Coord
+	// Delta returns back the shortest displacement from one coordinate to another
+	Delta(from, to Coord) Coord
+	// Span returns back the longest distance along each axis, used to normalize
+	// distances
+	Span() Coord
+}
+
+// Topology returns back the topology of the board, picked by the ruleset
+func (b Board) Topology() Topology {
+	if b.ruleset.rules().Wrapped {
+		return wrappedTopology{width: b.Width, height: b.Height}
+	}
+	return planarTopology{width: b.Width, height: b.Height}
+}
+
+// Manhattan returns back the number of moves between the coordinates on the board
+func (b Board) Manhattan(c1, c2 Coord) int {
+	d := b.Topology().Delta(c1, c2)
+	return abs(d.X) + abs(d.Y)
+}
+
+// Euclidean returns back the straight line distance between the coordinates on the board
+func (b Board) Euclidean(c1, c2 Coord) float64 {
+	d := b.Topology().Delta(c1, c2)
+	return math.Sqrt(float64(d.X*d.X + d.Y*d.Y))
+}
+
+// comparator returns back a function telling whether c1 lies in the direction
+// from c2, going the shortest way around the board
+func (b Board) comparator(dir Direction) func(c1, c2 Coord) bool {
+	t := b.Topology()
+	return func(c1, c2 Coord) bool {
+		d := t.Delta(c2, c1)
+		return d.X*dir.X > 0 || d.Y*dir.Y > 0
+	}
+}
+
+// planarTopology is the standard board surrounded by walls
+type planarTopology struct {
+	width, height int
+}
+
+func (t planarTopology) OutOfBounds(c Coord) bool {
+	return c.X >= t.width ||
+		c.X < 0 ||
+		c.Y >= t.height ||
+		c.Y < 0
+}
+
+func (t planarTopology) Move(c Coord, dir Direction) Coord {
+	return c.Add(Coord(dir))
+}
+
+func (t planarTopology) Delta(from, to Coord) Coord {
+	return to.Add(from.Reverse())
+}
+
+func (t planarTopology) Span() Coord {
+	return Coord{t.width, t.height}
+}
+
+// wrappedTopology is a board where every edge joins up with the opposite one
+type wrappedTopology struct {
+	width, height int
+}
+
+// OutOfBounds is only true for coordinates that weren't wrapped onto the board
+func (t wrappedTopology) OutOfBounds(c Coord) bool {
+	return planarTopology(t).OutOfBounds(c)
+}
+
+func (t wrappedTopology) Move(c Coord, dir Direction) Coord {
+	return Coord{wrap(c.X+dir.X, t.width), wrap(c.Y+dir.Y, t.height)}
+}
+
+func (t wrappedTopology) Delta(from, to Coord) Coord {
+	return Coord{shortest(to.X-from.X, t.width), shortest(to.Y-from.Y, t.height)}
+}
+
+func (t wrappedTopology) Span() Coord {
+	return Coord{t.width / 2, t.height / 2}
+}
+
+// wrap returns back v wrapped into [0, size)
+func wrap(v, size int) int {
+	if size <= 0 {
+		return v
+	}
+	return ((v % size) + size) % size
+}
+
+// shortest returns back the shortest way to cover a displacement of d around a
+// loop of the given size, positive on a tie
+func shortest(d, size int) int {
+	d = wrap(d, size)
+	if d > size/2 {
+		d -= size
+	}
+	return d
+}
+
+// stepDirection returns back the direction of a single move between adjacent
+// cells. A step of more than one cell can only have wrapped around the board,
+// so it was really one cell the other way.
+func stepDirection(from, to Coord) Direction {
+	d := to.Add(from.Reverse())
+	return Direction{unit(d.X), unit(d.Y)}
+}
+
+// unit returns back the direction of a step along one axis
+func unit(d int) int {
+	switch {
+	case d > 1:
+		return -1
+	case d < -1:
+		return 1
+	}
+	return d
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func wrappedBoard(width, height int) Board {
+	return Board{Width: width, Height: height, ruleset: Ruleset{Name: rulesetWrapped}}
+}
+
+func TestTopology(t *testing.T) {
+	planar := Board{Width: 11, Height: 11}
+	wrapped := wrappedBoard(11, 11)
+
+	assert.IsType(t, planarTopology{}, planar.Topology())
+	assert.IsType(t, wrappedTopology{}, wrapped.Topology())
+
+	tests := []struct {
+		name          string
+		board         Board
+		from          Coord
+		dir           Direction
+		wantNext      Coord
+		wantOutOfBnds bool
+	}{
+		{name: "planar inside", board: planar, from: Coord{5, 5}, dir: Direction_Up, wantNext: Coord{5, 6}},
+		{name: "planar off the left", board: planar, from: Coord{0, 5}, dir: Direction_Left, wantNext: Coord{-1, 5}, wantOutOfBnds: true},
+		{name: "wrapped inside", board: wrapped, from: Coord{5, 5}, dir: Direction_Up, wantNext: Coord{5, 6}},
+		{name: "wrapped off the left", board: wrapped, from: Coord{0, 5}, dir: Direction_Left, wantNext: Coord{10, 5}},
+		{name: "wrapped off the top", board: wrapped, from: Coord{3, 10}, dir: Direction_Up, wantNext: Coord{3, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.board.Topology().Move(tt.from, tt.dir)
+			assert.Equal(t, tt.wantNext, next)
+			assert.Equal(t, tt.wantOutOfBnds, tt.board.OutOfBounds(next))
+		})
+	}
+}
+
+func TestTopologyDistances(t *testing.T) {
+	planar := Board{Width: 11, Height: 11}
+	wrapped := wrappedBoard(11, 11)
+
+	assert.Equal(t, 10, planar.Manhattan(Coord{0, 5}, Coord{10, 5}))
+	assert.Equal(t, 1, wrapped.Manhattan(Coord{0, 5}, Coord{10, 5}))
+	assert.Equal(t, 6, wrapped.Manhattan(Coord{1, 1}, Coord{9, 9}))
+	assert.InDelta(t, 5.0, planar.Euclidean(Coord{0, 0}, Coord{3, 4}), 1e-9)
+	assert.InDelta(t, 5.0, wrapped.Euclidean(Coord{0, 0}, Coord{8, 4}), 1e-9)
+
+	assert.Equal(t, Coord{11, 11}, planar.Topology().Span())
+	assert.Equal(t, Coord{5, 5}, wrapped.Topology().Span())
+}
+
+func TestComparator(t *testing.T) {
+	planar := Board{Width: 11, Height: 11}
+	wrapped := wrappedBoard(11, 11)
+	head, food := Coord{0, 5}, Coord{10, 5}
+
+	assert.True(t, planar.comparator(Direction_Right)(food, head))
+	assert.False(t, planar.comparator(Direction_Left)(food, head))
+	assert.True(t, wrapped.comparator(Direction_Left)(food, head))
+	assert.False(t, wrapped.comparator(Direction_Right)(food, head))
+}
+
+func TestWrappedMoves(t *testing.T) {
+	me := newTestSnake("me", 100, Coord{0, 5}, Coord{10, 5}, Coord{9, 5})
+	assert.Equal(t, Direction_Right, me.Direction())
+	assert.Equal(t, Direction_Right, defaultDirection(me))
+
+	state := newTestState(11, 11, nil, me)
+	state.Board.ruleset = Ruleset{Name: rulesetWrapped}
+	state.Game.Ruleset = state.Board.ruleset
+	assert.ElementsMatch(t, []BattlesnakeMove{BattlesnakeMove_Up, BattlesnakeMove_Down, BattlesnakeMove_Right}, candidateMoves(me, state.Board))
+
+	// six moves down from the middle takes it off the bottom and back on at the top
+	next := state
+	for i := 0; i < 6; i++ {
+		next = Step(next, map[string]BattlesnakeMove{"me": BattlesnakeMove_Down})
+		assert.True(t, next.Alive("me"))
+	}
+	assert.Equal(t, Coord{0, 10}, next.You.Head)
+}
+
+func TestBitBoardTimedFloodFillWrapped(t *testing.T) {
+	// wrapping round the edges only ever opens up more room
+	for _, state := range randomGame(6, 100) {
+		state.Board.ruleset = Ruleset{Name: rulesetWrapped}
+		bb := newBitBoard(state.Board)
+		for _, snake := range state.Board.Snakes {
+			assert.GreaterOrEqual(t, bb.timedFloodFill(snake.Head, snake.Health).area, sliceOpenSpaces(snake.Head, state.Board))
+		}
+	}
+}
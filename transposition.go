@@ -0,0 +1,103 @@
+package main
+
+// This file contains the transposition table shared by every iteration of a
+// search within a turn. It is a fixed size array of entries indexed by the low
+// bits of the state's Zobrist hash.
+
+// ttSize is the number of entries in a transposition table, a power of two
+const ttSize = 1 << 16
+
+// ttBound says how the stored score relates to the true score of the state
+type ttBound uint8
+
+const (
+	ttExact ttBound = iota
+	// ttLower means the true score is at least the stored score
+	ttLower
+	// ttUpper means the true score is at most the stored score
+	ttUpper
+)
+
+type ttEntry struct {
+	hash uint64
+	// depth is how many turns below the state were searched
+	depth int
+	score float64
+	// scores are the scores of every snake, stored by the multiplayer search
+	scores scores
+	bound  ttBound
+	move   BattlesnakeMove
+	// cutoff is set when the depth ran out somewhere below the state
+	cutoff bool
+
+	generation int
+	used       bool
+}
+
+type transpositionTable struct {
+	entries []ttEntry
+	// generation counts the iterations of iterative deepening
+	generation int
+
+	hits   int
+	stores int
+}
+
+func newTranspositionTable(size int) *transpositionTable {
+	return &transpositionTable{
+		entries: make([]ttEntry, size),
+	}
+}
+
+// nextGeneration is called before every iteration so that entries from earlier
+// iterations are replaced first
+func (tt *transpositionTable) nextGeneration() {
+	tt.generation++
+}
+
+// lookup returns back the entry for the hash and whether there is one
+func (tt *transpositionTable) lookup(hash uint64) (ttEntry, bool) {
+	entry := tt.entries[hash&uint64(len(tt.entries)-1)]
+	if !entry.used || entry.hash != hash {
+		return ttEntry{}, false
+	}
+	tt.hits++
+	return entry, true
+}
+
+// store saves the entry unless its slot holds an entry of this generation that
+// was searched deeper
+func (tt *transpositionTable) store(entry ttEntry) {
+	slot := &tt.entries[entry.hash&uint64(len(tt.entries)-1)]
+	if slot.used && slot.generation == tt.generation && slot.depth > entry.depth {
+		return
+	}
+	entry.generation = tt.generation
+	entry.used = true
+	*slot = entry
+	tt.stores++
+}
+
+// ttScore converts a score relative to the root into one relative to the state
+// at ply, so that wins and losses found through transpositions at different
+// plies still prefer the quickest win and the slowest loss
+func ttScore(score float64, ply int) float64 {
+	switch proofOf(score) {
+	case provenWin:
+		return score + float64(ply)
+	case provenLoss:
+		return score - float64(ply)
+	}
+	return score
+}
+
+// rootScore converts a score stored with ttScore back to one relative to the root
+func rootScore(score float64, ply int) float64 {
+	switch proofOf(score) {
+	case provenWin:
+		return score - float64(ply)
+	case provenLoss:
+		return score + float64(ply)
+	}
+	return score
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	tt := newTranspositionTable(4)
+	tt.nextGeneration()
+
+	_, ok := tt.lookup(1)
+	assert.False(t, ok)
+
+	tt.store(ttEntry{hash: 1, depth: 3, score: 0.5, move: BattlesnakeMove_Up})
+	entry, ok := tt.lookup(1)
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, entry.score)
+	assert.Equal(t, BattlesnakeMove_Up, entry.move)
+
+	// 5 shares a slot with 1 but isn't the same state
+	_, ok = tt.lookup(5)
+	assert.False(t, ok)
+
+	// shallower entries of the same generation don't replace deeper ones
+	tt.store(ttEntry{hash: 5, depth: 2})
+	_, ok = tt.lookup(5)
+	assert.False(t, ok)
+
+	// anything replaces entries from earlier generations
+	tt.nextGeneration()
+	tt.store(ttEntry{hash: 5, depth: 1})
+	_, ok = tt.lookup(5)
+	assert.True(t, ok)
+	_, ok = tt.lookup(1)
+	assert.False(t, ok)
+}
+
+func TestTTScore(t *testing.T) {
+	for _, score := range []float64{winScore - 2, lossScore + 2, 0.3} {
+		assert.Equal(t, score, rootScore(ttScore(score, 5), 5))
+	}
+	// a win found two turns below a state five turns deep is a win in two turns
+	// from that state, which is a win in three turns from a state four turns deep
+	assert.Equal(t, winScore-7, rootScore(ttScore(winScore-7, 5), 5))
+	assert.Equal(t, winScore-6, rootScore(ttScore(winScore-7, 5), 4))
+}
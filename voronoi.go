@@ -0,0 +1,102 @@
+package main
+
+// This file contains the territory evaluator. Every snake floods out from its
+// head at the same time and each free cell belongs to the snake that reaches it
+// first. When snakes reach a cell on the same turn the longest of them gets it,
+// since it would win the head-to-head; if the longest are the same length the
+// cell is contested and belongs to nobody.
+
+type territory struct {
+	// cells is the number of cells the snake reaches first, not counting its head
+	cells int
+	// food is the number of food within those cells
+	food int
+}
+
+type voronoiResult struct {
+	territories map[string]territory
+	// contested are the cells reached on the same turn by snakes of equal length
+	contested []Coord
+}
+
+const (
+	voronoiUnclaimed = -1
+	voronoiContested = -2
+)
+
+// voronoi splits the free cells of the board between the snakes on it
+func voronoi(board Board) voronoiResult {
+	bb := newBitBoard(board)
+	cells := board.Width * board.Height
+	owner := make([]int, cells)
+	for i := range owner {
+		owner[i] = voronoiUnclaimed
+	}
+
+	result := voronoiResult{
+		territories: make(map[string]territory, len(board.Snakes)),
+		contested:   []Coord{},
+	}
+	counts := make([]territory, len(board.Snakes))
+
+	frontier := []int{}
+	for i, snake := range board.Snakes {
+		if board.OutOfBounds(snake.Head) {
+			continue
+		}
+		head := bb.index(snake.Head)
+		owner[head] = i
+		frontier = append(frontier, head)
+	}
+
+	// the longest snake claiming each cell this turn, and its length
+	claimOwner := make([]int, cells)
+	claimLength := make([]int, cells)
+	for len(frontier) > 0 {
+		claimed := []int{}
+		for _, cell := range frontier {
+			o := owner[cell]
+			length := len(board.Snakes[o].Body)
+			c := bb.coord(cell)
+			for _, dir := range directions {
+				n := bb.topology.Move(c, dir)
+				if bb.outOfBounds(n) {
+					continue
+				}
+				i := bb.index(n)
+				if owner[i] != voronoiUnclaimed || bb.blocked(i) {
+					continue
+				}
+				switch {
+				case claimLength[i] == 0:
+					claimed = append(claimed, i)
+					claimOwner[i], claimLength[i] = o, length
+				case length > claimLength[i]:
+					claimOwner[i], claimLength[i] = o, length
+				case length == claimLength[i] && claimOwner[i] != o:
+					claimOwner[i] = voronoiContested
+				}
+			}
+		}
+
+		frontier = frontier[:0]
+		for _, i := range claimed {
+			owner[i] = claimOwner[i]
+			claimLength[i] = 0
+			if owner[i] == voronoiContested {
+				result.contested = append(result.contested, bb.coord(i))
+				continue
+			}
+			counts[owner[i]].cells++
+			if bb.food.has(i) {
+				counts[owner[i]].food++
+			}
+			frontier = append(frontier, i)
+		}
+	}
+
+	for i, snake := range board.Snakes {
+		result.territories[snake.ID] = counts[i]
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoronoi(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       GameState
+		territories map[string]territory
+		contested   []Coord
+	}{
+		{
+			name: "alone",
+			state: newTestState(3, 3, []Coord{{0, 0}, {2, 2}},
+				newTestSnake("a", 100, Coord{1, 1}),
+			),
+			territories: map[string]territory{"a": {cells: 8, food: 2}},
+			contested:   []Coord{},
+		},
+		{
+			name: "equal lengths contest the middle",
+			state: newTestState(5, 1, []Coord{{1, 0}},
+				newTestSnake("a", 100, Coord{0, 0}),
+				newTestSnake("b", 100, Coord{4, 0}),
+			),
+			territories: map[string]territory{"a": {cells: 1, food: 1}, "b": {cells: 1}},
+			contested:   []Coord{{2, 0}},
+		},
+		{
+			name: "longer snake wins the tie",
+			state: newTestState(5, 1, nil,
+				newTestSnake("a", 100, Coord{0, 0}),
+				newTestSnake("b", 100, Coord{4, 0}, Coord{4, 0}),
+			),
+			territories: map[string]territory{"a": {cells: 1}, "b": {cells: 2}},
+			contested:   []Coord{},
+		},
+		{
+			name: "longer snake overrides a contest",
+			state: newTestState(3, 2, nil,
+				newTestSnake("a", 100, Coord{0, 1}),
+				newTestSnake("b", 100, Coord{2, 1}),
+				newTestSnake("c", 100, Coord{1, 0}, Coord{1, 0}),
+			),
+			territories: map[string]territory{"a": {}, "b": {}, "c": {cells: 3}},
+			contested:   []Coord{},
+		},
+		{
+			name: "bodies block the way",
+			state: newTestState(3, 3, nil,
+				newTestSnake("a", 100, Coord{0, 0}),
+				newTestSnake("b", 100, Coord{2, 2}, Coord{1, 2}, Coord{1, 1}, Coord{1, 0}),
+			),
+			territories: map[string]territory{"a": {cells: 3}, "b": {cells: 2}},
+			contested:   []Coord{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := voronoi(tt.state.Board)
+			assert.Equal(t, tt.territories, result.territories)
+			assert.ElementsMatch(t, tt.contested, result.contested)
+		})
+	}
+}
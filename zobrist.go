@@ -0,0 +1,71 @@
+package main
+
+// This file contains Zobrist hashing of game states. Every feature of a state (a
+// snake's segment on a cell, its health, food or hazard on a cell) has its own
+// pseudo-random key and the hash of a state is the XOR of the keys of all its
+// features, so the same position reached by different move orders hashes the
+// same.
+//
+// Keys are derived by mixing the feature instead of being looked up in a table,
+// which works for any board size or snake ID.
+
+import (
+	"hash/fnv"
+)
+
+const (
+	zobristHead uint64 = iota + 1
+	zobristBody
+	zobristHealth
+	zobristFood
+	zobristHazard
+)
+
+// zobristKey returns back the pseudo-random key of a feature
+func zobristKey(kind, a, b uint64) uint64 {
+	return splitmix64(splitmix64(splitmix64(kind)^a) ^ b)
+}
+
+// splitmix64 is the finalizer of the SplitMix64 generator, which scatters every
+// bit of the input across the output
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// zobristHash returns back the hash of the state's board: every snake's head,
+// body layout and health along with the food and hazards
+func zobristHash(state GameState) uint64 {
+	board := state.Board
+	cell := func(c Coord) uint64 {
+		return uint64(c.Y*board.Width + c.X)
+	}
+
+	var hash uint64
+	for _, snake := range board.Snakes {
+		id := snakeKey(snake.ID)
+		hash ^= zobristKey(zobristHead, id, cell(snake.Head))
+		hash ^= zobristKey(zobristHealth, id, uint64(snake.Health))
+		for i, c := range snake.Body[1:] {
+			// the segment's position along the body is part of the key, so stacked
+			// segments don't cancel each other out and the tail end is known
+			hash ^= zobristKey(zobristBody, id^uint64(i+1)<<32, cell(c))
+		}
+	}
+	for _, f := range board.Food {
+		hash ^= zobristKey(zobristFood, 0, cell(f))
+	}
+	for _, h := range board.Hazards {
+		hash ^= zobristKey(zobristHazard, 0, cell(h))
+	}
+	return hash
+}
+
+// snakeKey returns back a key identifying the snake
+func snakeKey(id string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	return h.Sum64()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZobristHash(t *testing.T) {
+	base := newTestState(7, 7, []Coord{{3, 3}},
+		newTestSnake("a", 90, Coord{1, 1}, Coord{1, 0}, Coord{0, 0}),
+		newTestSnake("b", 90, Coord{5, 5}, Coord{5, 6}, Coord{6, 6}),
+	)
+	hash := zobristHash(base)
+
+	t.Run("snake order does not matter", func(t *testing.T) {
+		state := base
+		state.Board.Snakes = []Battlesnake{base.Board.Snakes[1], base.Board.Snakes[0]}
+		assert.Equal(t, hash, zobristHash(state))
+	})
+
+	t.Run("transpositions hash the same", func(t *testing.T) {
+		state := newTestState(7, 7, nil,
+			newTestSnake("a", 90, Coord{1, 1}),
+			newTestSnake("b", 90, Coord{5, 5}, Coord{5, 6}, Coord{6, 6}),
+		)
+		first := Step(state, map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Left})
+		first = Step(first, map[string]BattlesnakeMove{"a": BattlesnakeMove_Right, "b": BattlesnakeMove_Left})
+		second := Step(state, map[string]BattlesnakeMove{"a": BattlesnakeMove_Right, "b": BattlesnakeMove_Left})
+		second = Step(second, map[string]BattlesnakeMove{"a": BattlesnakeMove_Up, "b": BattlesnakeMove_Left})
+		assert.Equal(t, zobristHash(first), zobristHash(second))
+		assert.NotEqual(t, zobristHash(state), zobristHash(first))
+	})
+
+	changes := map[string]func(state *GameState){
+		"health": func(state *GameState) { state.Board.Snakes[0].Health-- },
+		"head": func(state *GameState) {
+			state.Board.Snakes[0].Body[0] = Coord{2, 1}
+			state.Board.Snakes[0].Head = Coord{2, 1}
+		},
+		"tail": func(state *GameState) { state.Board.Snakes[0].Body[2] = Coord{2, 0} },
+		"growth": func(state *GameState) {
+			state.Board.Snakes[0].Body = append(state.Board.Snakes[0].Body, Coord{0, 0})
+		},
+		"food":    func(state *GameState) { state.Board.Food = []Coord{{3, 4}} },
+		"hazards": func(state *GameState) { state.Board.Hazards = []Coord{{0, 6}} },
+		"owner": func(state *GameState) {
+			state.Board.Snakes[0].ID, state.Board.Snakes[1].ID = state.Board.Snakes[1].ID, state.Board.Snakes[0].ID
+		},
+	}
+	for name, change := range changes {
+		t.Run(name+" changes the hash", func(t *testing.T) {
+			state := base
+			state.Board.Snakes = make([]Battlesnake, len(base.Board.Snakes))
+			for i, snake := range base.Board.Snakes {
+				snake.Body = append([]Coord{}, snake.Body...)
+				state.Board.Snakes[i] = snake
+			}
+			change(&state)
+			assert.NotEqual(t, hash, zobristHash(state))
+		})
+	}
+}